@@ -0,0 +1,90 @@
+package gkit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// AuditRecord describes a single call of an audited endpoint.
+type AuditRecord struct {
+	// Principal, Endpoint, RequestID and Tenant are read from the context
+	// with PrincipalFromContext, EndpointNameFromContext, RequestIDFromContext
+	// and TenantFromContext.
+	Principal string
+	Endpoint  string
+	RequestID string
+	Tenant    string
+
+	// Fields are the request fields returned by the FieldExtractor given to
+	// Audit, in the style of slog.Logger.With.
+	Fields []any
+
+	// Time is when the call started and Duration how long it took.
+	Time     time.Time
+	Duration time.Duration
+
+	// Err is the error returned by the endpoint, or nil on success.
+	Err error
+}
+
+// AuditSink stores audit records, e.g. in an append-only log.
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc is an adapter to use a function as an AuditSink.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// Audit implements AuditSink.
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// Audit returns a Middleware recording who called the endpoint, with which
+// request fields, when and with what outcome to sink. Only the fields
+// returned by extract are recorded, so sensitive fields stay out of the audit
+// log; a nil extract means NoFields.
+//
+// A record is written for every call, including failed calls and calls that
+// panic, after which the panic is resumed. The sink is called with a context
+// that isn't canceled with the request, and an error returned by it is logged
+// with LoggerFromContext, as the outcome of the call is already decided.
+func Audit[Req, Res any](sink AuditSink, extract FieldExtractor[Req]) Middleware[Req, Res] {
+	if extract == nil {
+		extract = NoFields[Req]
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (response Res, err error) {
+			record := AuditRecord{
+				Principal: PrincipalFromContext(ctx),
+				Endpoint:  EndpointNameFromContext(ctx),
+				RequestID: RequestIDFromContext(ctx),
+				Tenant:    TenantFromContext(ctx),
+				Fields:    extract(request),
+				Time:      time.Now(),
+			}
+
+			defer func() {
+				p := recover()
+				record.Duration, record.Err = time.Since(record.Time), err
+				if p != nil {
+					record.Err = fmt.Errorf("gkit: endpoint panicked: %v", p)
+				}
+
+				if sinkErr := sink.Audit(context.WithoutCancel(ctx), record); sinkErr != nil {
+					LoggerFromContext(ctx).ErrorContext(ctx, "gkit: writing audit record failed",
+						slog.String("endpoint", record.Endpoint), slog.Any("error", sinkErr))
+				}
+
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
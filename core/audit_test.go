@@ -0,0 +1,68 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type transfer struct {
+	Account string
+	PIN     string
+}
+
+func TestAudit(t *testing.T) {
+	var records []gkit.AuditRecord
+	sink := gkit.AuditSinkFunc(func(ctx context.Context, r gkit.AuditRecord) error {
+		if ctx.Err() != nil {
+			t.Errorf("sink called with done context: %v", ctx.Err())
+		}
+		records = append(records, r)
+		return nil
+	})
+
+	errDeclined := errors.New("declined")
+	audit := gkit.Audit[transfer, struct{}](sink, func(req transfer) []any {
+		return []any{"account", req.Account}
+	})
+	e := gkit.Chain(gkit.Named[transfer, struct{}]("transfer"), audit)(func(ctx context.Context, req transfer) (struct{}, error) {
+		if req.Account == "panic" {
+			panic("boom")
+		}
+		return struct{}{}, errDeclined
+	})
+
+	ctx, cancel := context.WithCancel(gkit.WithPrincipal(context.Background(), "user-1"))
+	cancel()
+
+	if _, err := e(ctx, transfer{Account: "acc-1", PIN: "1234"}); err != errDeclined {
+		t.Errorf("want %v, have %v", errDeclined, err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic not resumed")
+			}
+		}()
+		e(ctx, transfer{Account: "panic"})
+	}()
+
+	if want, have := 2, len(records); want != have {
+		t.Fatalf("want %d records, have %d", want, have)
+	}
+
+	r := records[0]
+	if r.Principal != "user-1" || r.Endpoint != "transfer" || r.Err != errDeclined || r.Time.IsZero() {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if want, have := []any{"account", "acc-1"}, r.Fields; len(have) != 2 || have[0] != want[0] || have[1] != want[1] {
+		t.Errorf("Fields: want %v, have %v", want, have)
+	}
+	if records[1].Err == nil {
+		t.Error("panicking call recorded without error")
+	}
+}
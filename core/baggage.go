@@ -0,0 +1,157 @@
+package gkit
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// BaggageKey is the header, or metadata key, carrying the baggage of a
+// request as defined by the W3C Baggage specification, so cross-cutting
+// key-values such as a tenant or an experiment flow across services. The
+// value is formatted by FormatBaggage.
+const BaggageKey = "baggage"
+
+// Limits of the W3C Baggage specification, enforced by ParseBaggage and
+// FormatBaggage.
+const (
+	MaxBaggageMembers = 64
+	MaxBaggageBytes   = 8192
+)
+
+// Baggage holds the key-values propagated with a request. Member properties
+// aren't supported and are dropped when parsing.
+type Baggage map[string]string
+
+type baggageKey struct{}
+
+// WithBaggage returns a copy of ctx carrying b. Transports store the baggage
+// received with a request, and send the one of the context along with their
+// outgoing requests.
+func WithBaggage(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// WithBaggageValue returns a copy of ctx carrying the baggage of ctx with key
+// set to value. The baggage of ctx is left untouched.
+func WithBaggageValue(ctx context.Context, key, value string) context.Context {
+	parent := BaggageFromContext(ctx)
+	b := make(Baggage, len(parent)+1)
+	for k, v := range parent {
+		b[k] = v
+	}
+	b[key] = value
+	return WithBaggage(ctx, b)
+}
+
+// BaggageFromContext returns the baggage stored by WithBaggage, or nil if
+// there is none. It must not be modified; use WithBaggageValue instead.
+func BaggageFromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	return b
+}
+
+// ParseBaggage parses a baggage header value. Malformed members are skipped,
+// as are the members past MaxBaggageMembers, and values are percent-decoded.
+// A value longer than MaxBaggageBytes is ignored altogether.
+func ParseBaggage(s string) Baggage {
+	if s == "" || len(s) > MaxBaggageBytes {
+		return nil
+	}
+
+	b := make(Baggage)
+	for _, member := range strings.Split(s, ",") {
+		if len(b) == MaxBaggageMembers {
+			break
+		}
+
+		member, _, _ = strings.Cut(member, ";")
+		key, value, ok := strings.Cut(member, "=")
+		key = strings.Trim(key, " \t")
+		if !ok || !isBaggageKey(key) {
+			continue
+		}
+		value, err := url.PathUnescape(strings.Trim(value, " \t"))
+		if err != nil {
+			continue
+		}
+		b[key] = value
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
+
+// FormatBaggage formats b as a baggage header value, with members sorted by
+// key and values percent-encoded. Members with an invalid key are skipped.
+// Members that would exceed MaxBaggageMembers or MaxBaggageBytes are
+// dropped as a whole, never truncated.
+func FormatBaggage(b Baggage) string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		if isBaggageKey(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		sb      strings.Builder
+		members int
+	)
+	for _, k := range keys {
+		member := k + "=" + escapeBaggageValue(b[k])
+		size := len(member)
+		if members > 0 {
+			size++
+		}
+		if members == MaxBaggageMembers || sb.Len()+size > MaxBaggageBytes {
+			continue
+		}
+
+		if members > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(member)
+		members++
+	}
+	return sb.String()
+}
+
+// isBaggageKey reports whether key is an RFC 7230 token.
+func isBaggageKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// escapeBaggageValue percent-encodes the bytes of value that aren't a
+// baggage-octet, along with the percent sign.
+func escapeBaggageValue(value string) string {
+	const hex = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == ',' || c == ';' || c == '\\' || c == '%' {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&0xf])
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
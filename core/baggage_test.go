@@ -0,0 +1,53 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestBaggageFormat(t *testing.T) {
+	b := gkit.ParseBaggage(" tenant = acme ;ttl=60, bad key=x, experiment=a%2Cb,novalue, =empty")
+	if want, have := 2, len(b); want != have {
+		t.Fatalf("want %d members, have %v", want, b)
+	}
+	if want, have := "a,b", b["experiment"]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "experiment=a%2Cb,tenant=acme", gkit.FormatBaggage(b); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	many := make(gkit.Baggage)
+	for i := 0; i < 2*gkit.MaxBaggageMembers; i++ {
+		many["k"+strconv.Itoa(i)] = "v"
+	}
+	if want, have := gkit.MaxBaggageMembers, strings.Count(gkit.FormatBaggage(many), ",")+1; want != have {
+		t.Errorf("want %d members formatted, have %d", want, have)
+	}
+
+	large := gkit.Baggage{"a": strings.Repeat("x", gkit.MaxBaggageBytes), "b": "small"}
+	if want, have := "b=small", gkit.FormatBaggage(large); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if b := gkit.ParseBaggage("a=" + strings.Repeat("x", gkit.MaxBaggageBytes)); b != nil {
+		t.Errorf("want oversized header ignored, have %d members", len(b))
+	}
+}
+
+func TestWithBaggageValue(t *testing.T) {
+	parent := gkit.WithBaggageValue(context.Background(), "tenant", "acme")
+	child := gkit.WithBaggageValue(parent, "experiment", "b")
+
+	if want, have := 1, len(gkit.BaggageFromContext(parent)); want != have {
+		t.Errorf("parent: want %d member, have %d", want, have)
+	}
+	if want, have := "acme", gkit.BaggageFromContext(child)["tenant"]; want != have {
+		t.Errorf("child: want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,176 @@
+package gkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Cache stores opaque values by key for a limited time. Implementations may
+// be backed by Redis, memcached or, for tests and single instances,
+// MemoryCache. A miss is reported with ok set to false and a nil error.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is an in-process Cache. Expired entries are dropped lazily when
+// they're read.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]cacheEntry)}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set implements Cache.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+type cacheConfig struct {
+	negativeTTL time.Duration
+	negative    []error
+}
+
+// CacheOption sets an optional parameter for CacheResponses.
+type CacheOption Option[*cacheConfig]
+
+// CacheNegative enables negative caching: an error matching one of errs, as
+// reported by errors.Is, is stored for ttl and returned to later calls for
+// the same key without calling the next endpoint. This spares the backend
+// from lookups of hot keys known to be absent, e.g. with errs set to a
+// package's ErrNotFound. Keep ttl short, since a key created in the meantime
+// stays reported as absent until the entry expires.
+//
+// The error returned from the cache has the message of the original error
+// and wraps the one of errs it matched, so errors.Is still reports it. Other
+// details of the original error, e.g. its type, are lost, as entries are
+// serialized.
+//
+// Only the listed errors are cached, so transient failures are always
+// retried. Context cancellation and deadline errors are never cached, even
+// when they wrap one of errs.
+func CacheNegative(ttl time.Duration, errs ...error) CacheOption {
+	return func(c *cacheConfig) {
+		c.negativeTTL = ttl
+		c.negative = errs
+	}
+}
+
+// cachedResult is the serialized form of a result stored by CacheResponses.
+// Error is 0 for a response, or the 1-based index of the negative error, and
+// Message the message of the original error.
+type cachedResult[Res any] struct {
+	Response Res    `json:"response"`
+	Error    int    `json:"error,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// cachedError is a negative error read from the cache, with the message of
+// the original error, wrapping the negative error it matched.
+type cachedError struct {
+	msg string
+	err error
+}
+
+func (e *cachedError) Error() string { return e.msg }
+
+func (e *cachedError) Unwrap() error { return e.err }
+
+// CacheResponses returns a Middleware that stores the responses of the next
+// endpoint in cache for ttl, as JSON, and answers later calls with the same
+// key from the cache. Calls for which key returns an empty string always
+// reach next. Errors are not cached unless CacheNegative is given. Cache
+// errors are treated as misses, so an unavailable cache degrades to calling
+// next.
+func CacheResponses[Req, Res any](cache Cache, ttl time.Duration, key func(ctx context.Context, request Req) string, options ...CacheOption) Middleware[Req, Res] {
+	cfg := &cacheConfig{}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			k := key(ctx, request)
+			if k == "" {
+				return next(ctx, request)
+			}
+
+			if b, ok, err := cache.Get(ctx, k); err == nil && ok {
+				var stored cachedResult[Res]
+				if err := json.Unmarshal(b, &stored); err == nil {
+					switch {
+					case stored.Error == 0:
+						return stored.Response, nil
+					case stored.Error <= len(cfg.negative):
+						var res Res
+						return res, &cachedError{msg: stored.Message, err: cfg.negative[stored.Error-1]}
+					}
+				}
+			}
+
+			res, err := next(ctx, request)
+			var (
+				stored = cachedResult[Res]{Response: res}
+				d      = ttl
+			)
+			if err != nil {
+				i := cfg.negativeIndex(err)
+				if i < 0 {
+					return res, err
+				}
+				stored = cachedResult[Res]{Error: i + 1, Message: err.Error()}
+				d = cfg.negativeTTL
+			}
+			if b, merr := json.Marshal(stored); merr == nil {
+				cache.Set(ctx, k, b, d) //nolint:errcheck
+			}
+			return res, err
+		}
+	}
+}
+
+// negativeIndex returns the index of the negative error matching err, or -1
+// if err must not be cached.
+func (c *cacheConfig) negativeIndex(err error) int {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return -1
+	}
+	for i, target := range c.negative {
+		if errors.Is(err, target) {
+			return i
+		}
+	}
+	return -1
+}
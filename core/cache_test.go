@@ -0,0 +1,97 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+var errNotFound = errors.New("not found")
+
+func cacheKey(_ context.Context, id string) string { return id }
+
+func TestCacheResponses(t *testing.T) {
+	var calls int
+	next := func(_ context.Context, id string) (string, error) {
+		calls++
+		return "user " + id, nil
+	}
+
+	e := gkit.CacheResponses[string, string](gkit.NewMemoryCache(), time.Minute, cacheKey)(next)
+	for i := 0; i < 3; i++ {
+		have, err := e(context.Background(), "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "user 1"; want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
+
+func TestCacheResponsesNegative(t *testing.T) {
+	var (
+		calls int
+		errs  = map[string]error{
+			"absent":    fmt.Errorf("user absent: %w", errNotFound),
+			"transient": errors.New("connection reset"),
+			"canceled":  fmt.Errorf("%w: %w", errNotFound, context.Canceled),
+		}
+	)
+	next := func(_ context.Context, id string) (string, error) {
+		calls++
+		return "", errs[id]
+	}
+
+	e := gkit.CacheResponses[string, string](gkit.NewMemoryCache(), time.Minute, cacheKey,
+		gkit.CacheNegative(time.Minute, errNotFound),
+	)(next)
+
+	for id, wantCalls := range map[string]int{"absent": 1, "transient": 2, "canceled": 2} {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			if _, err := e(context.Background(), id); err == nil {
+				t.Fatalf("%s: want error, have none", id)
+			}
+		}
+		if wantCalls != calls {
+			t.Errorf("%s: want %d calls, have %d", id, wantCalls, calls)
+		}
+	}
+
+	_, err := e(context.Background(), "absent")
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("cached: want %v, have %v", errNotFound, err)
+	}
+	if want, have := errs["absent"].Error(), err.Error(); want != have {
+		t.Errorf("cached: want message %q, have %q", want, have)
+	}
+}
+
+func TestCacheResponsesNegativeTTL(t *testing.T) {
+	var calls int
+	next := func(context.Context, string) (string, error) {
+		calls++
+		return "", errNotFound
+	}
+
+	e := gkit.CacheResponses[string, string](gkit.NewMemoryCache(), time.Minute, cacheKey,
+		gkit.CacheNegative(10*time.Millisecond, errNotFound),
+	)(next)
+
+	e(context.Background(), "absent") //nolint:errcheck
+	time.Sleep(20 * time.Millisecond)
+	e(context.Background(), "absent") //nolint:errcheck
+	if want, have := 2, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
@@ -0,0 +1,62 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrTooManyItems is wrapped by the error returned by a CapItems endpoint
+// when the response holds more items than allowed and no truncate func was
+// given.
+var ErrTooManyItems = errors.New("too many items")
+
+// Lener is implemented by list responses, e.g. a page of results, returning
+// the number of items they hold. It's used by CapItems when no count func is
+// given.
+type Lener interface {
+	Len() int
+}
+
+// CapItems returns a Middleware guarding list endpoints against unbounded
+// responses, e.g. an accidental full-table scan. count returns the number of
+// items of a response; when nil, responses are counted with Lener, and
+// CapItems panics if Res doesn't implement it, as no response could ever be
+// capped. When a response holds more than max items, it's passed to truncate,
+// which returns it with only the first max items, and typically sets a flag
+// telling the client the list was truncated. When truncate is nil, the
+// endpoint returns an error wrapping ErrTooManyItems instead. Responses
+// returned along with an error are left untouched.
+func CapItems[Req, Res any](max int, count func(Res) int, truncate func(response Res, max int) Res) Middleware[Req, Res] {
+	if count == nil {
+		if t := reflect.TypeOf((*Res)(nil)).Elem(); !t.Implements(reflect.TypeOf((*Lener)(nil)).Elem()) {
+			panic(fmt.Sprintf("gkit: CapItems with a nil count, but %s doesn't implement Lener", t))
+		}
+		count = func(response Res) int {
+			if l, ok := any(response).(Lener); ok {
+				return l.Len()
+			}
+			return 0
+		}
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			n := count(response)
+			if n <= max {
+				return response, nil
+			}
+			if truncate == nil {
+				var zero Res
+				return zero, fmt.Errorf("%w: %d items, max %d", ErrTooManyItems, n, max)
+			}
+			return truncate(response, max), nil
+		}
+	}
+}
@@ -0,0 +1,59 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type userList struct {
+	Users     []string
+	Truncated bool
+}
+
+func (l userList) Len() int { return len(l.Users) }
+
+func TestCapItems(t *testing.T) {
+	list := func(_ context.Context, n int) (userList, error) {
+		return userList{Users: make([]string, n)}, nil
+	}
+	truncate := func(l userList, max int) userList {
+		return userList{Users: l.Users[:max], Truncated: true}
+	}
+
+	e := gkit.CapItems[int, userList](3, nil, truncate)(list)
+	for n, want := range map[int]userList{
+		2: {Users: make([]string, 2)},
+		3: {Users: make([]string, 3)},
+		5: {Users: make([]string, 3), Truncated: true},
+	} {
+		have, err := e(context.Background(), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want.Len() != have.Len() || want.Truncated != have.Truncated {
+			t.Errorf("%d items: want %+v, have %+v", n, want, have)
+		}
+	}
+
+	e = gkit.CapItems[int, userList](3, func(l userList) int { return len(l.Users) }, nil)(list)
+	if _, err := e(context.Background(), 5); !errors.Is(err, gkit.ErrTooManyItems) {
+		t.Errorf("want %v, have %v", gkit.ErrTooManyItems, err)
+	}
+	if _, err := e(context.Background(), 3); err != nil {
+		t.Errorf("want no error at the cap, have %v", err)
+	}
+}
+
+func TestCapItemsNotLener(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want a panic for a nil count and a Res not implementing Lener")
+		}
+	}()
+	gkit.CapItems[int, []string](3, nil, nil)
+}
@@ -0,0 +1,150 @@
+package gkit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key of
+// the request being processed.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key stored by
+// WithIdempotencyKey, or an empty string if there is none.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// IdempotencyKey is a key func for Coalesce that coalesces requests carrying
+// the same idempotency key in their context.
+func IdempotencyKey[Req any](ctx context.Context, _ Req) string {
+	return IdempotencyKeyFromContext(ctx)
+}
+
+// call is an in-flight or completed Coalesce call.
+type call[Res any] struct {
+	done chan struct{}
+	res  Res
+	err  error
+}
+
+// CoalesceCounter counts the calls of a Coalesce middleware, to measure how
+// effective coalescing is. It is updated atomically, so it can be read at any
+// time, e.g. by a metrics exporter.
+type CoalesceCounter struct {
+	leaders atomic.Uint64
+	shared  atomic.Uint64
+}
+
+// Leaders returns the number of calls that invoked the next endpoint.
+func (c *CoalesceCounter) Leaders() uint64 {
+	return c.leaders.Load()
+}
+
+// Shared returns the number of calls that were handed the result of a
+// concurrent call instead of invoking the next endpoint.
+func (c *CoalesceCounter) Shared() uint64 {
+	return c.shared.Load()
+}
+
+type coalesceConfig struct {
+	counter  *CoalesceCounter
+	onLeader func(ctx context.Context, key string)
+	onShared func(ctx context.Context, key string)
+}
+
+// CoalesceOption sets an optional parameter for Coalesce.
+type CoalesceOption Option[*coalesceConfig]
+
+// CoalesceCount makes Coalesce count its leader and shared calls in c. Calls
+// without a key aren't counted.
+func CoalesceCount(c *CoalesceCounter) CoalesceOption {
+	return func(cfg *coalesceConfig) { cfg.counter = c }
+}
+
+// CoalesceOnLeader sets a callback invoked with the key of every call that
+// invokes the next endpoint, before it does.
+func CoalesceOnLeader(fn func(ctx context.Context, key string)) CoalesceOption {
+	return func(cfg *coalesceConfig) { cfg.onLeader = fn }
+}
+
+// CoalesceOnShared sets a callback invoked with the key of every call that
+// waits for a concurrent call instead of invoking the next endpoint, before
+// it waits.
+func CoalesceOnShared(fn func(ctx context.Context, key string)) CoalesceOption {
+	return func(cfg *coalesceConfig) { cfg.onShared = fn }
+}
+
+// Coalesce returns a Middleware that makes concurrent calls sharing the same
+// key wait for a single call to next, and hands all of them its response and
+// error. Calls for which key returns an empty string are never coalesced.
+//
+// Only calls that overlap in time are coalesced: once the shared call returns,
+// the next call with the same key, such as a retry after a failure, invokes
+// next again. The shared call runs with the context of the first caller, so
+// its cancellation is observed by every caller waiting on it. A waiting caller
+// whose own context is done stops waiting and returns its error.
+func Coalesce[Req, Res any](key func(ctx context.Context, request Req) string, options ...CoalesceOption) Middleware[Req, Res] {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]*call[Res])
+		cfg   = &coalesceConfig{}
+	)
+	for _, option := range options {
+		option(cfg)
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			k := key(ctx, request)
+			if k == "" {
+				return next(ctx, request)
+			}
+
+			mu.Lock()
+			if c, ok := calls[k]; ok {
+				mu.Unlock()
+				if cfg.counter != nil {
+					cfg.counter.shared.Add(1)
+				}
+				if cfg.onShared != nil {
+					cfg.onShared(ctx, k)
+				}
+				select {
+				case <-c.done:
+					return c.res, c.err
+				case <-ctx.Done():
+					var zero Res
+					return zero, ctx.Err()
+				}
+			}
+			c := &call[Res]{done: make(chan struct{})}
+			calls[k] = c
+			mu.Unlock()
+
+			if cfg.counter != nil {
+				cfg.counter.leaders.Add(1)
+			}
+			if cfg.onLeader != nil {
+				cfg.onLeader(ctx, k)
+			}
+
+			defer func() {
+				mu.Lock()
+				delete(calls, k)
+				mu.Unlock()
+				close(c.done)
+			}()
+
+			c.res, c.err = next(ctx, request)
+
+			return c.res, c.err
+		}
+	}
+}
@@ -0,0 +1,147 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestCoalesceIdempotencyKey(t *testing.T) {
+	var (
+		calls   int32
+		release = make(chan struct{})
+		started = make(chan struct{})
+		joined  = make(chan struct{})
+	)
+
+	onShared := gkit.CoalesceOnShared(func(context.Context, string) { close(joined) })
+	e := gkit.Coalesce[struct{}, int32](gkit.IdempotencyKey[struct{}], onShared)(func(context.Context, struct{}) (int32, error) {
+		n := atomic.AddInt32(&calls, 1)
+		close(started)
+		<-release
+		return n, nil
+	})
+
+	ctx := gkit.WithIdempotencyKey(context.Background(), "charge-1")
+
+	var (
+		wg      sync.WaitGroup
+		results [2]int32
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _ = e(ctx, struct{}{})
+	}()
+	<-started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], _ = e(ctx, struct{}{})
+	}()
+
+	<-joined
+	close(release)
+	wg.Wait()
+
+	if want, have := int32(1), atomic.LoadInt32(&calls); want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+	if results[0] != results[1] {
+		t.Errorf("want same response, have %v", results)
+	}
+}
+
+func TestCoalesceWaiterContextDone(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		started = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	e := gkit.Coalesce[struct{}, struct{}](gkit.IdempotencyKey[struct{}])(func(context.Context, struct{}) (struct{}, error) {
+		close(started)
+		<-release
+		return struct{}{}, nil
+	})
+
+	ctx := gkit.WithIdempotencyKey(context.Background(), "charge-1")
+	go func() {
+		defer close(done)
+		e(ctx, struct{}{})
+	}()
+	<-started
+
+	waiterCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := e(waiterCtx, struct{}{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("want %v, have %v", context.Canceled, err)
+	}
+	close(release)
+	<-done
+}
+
+func TestCoalesceWithoutKey(t *testing.T) {
+	var calls int
+	e := gkit.Coalesce[struct{}, struct{}](gkit.IdempotencyKey[struct{}])(func(context.Context, struct{}) (struct{}, error) {
+		calls++
+		return struct{}{}, nil
+	})
+
+	e(context.Background(), struct{}{})
+	e(context.Background(), struct{}{})
+
+	if want, have := 2, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestCoalesceCounter(t *testing.T) {
+	var (
+		counter gkit.CoalesceCounter
+		release = make(chan struct{})
+		leader  = make(chan string, 1)
+		shared  = make(chan string, 3)
+	)
+
+	e := gkit.Coalesce[struct{}, struct{}](gkit.IdempotencyKey[struct{}],
+		gkit.CoalesceCount(&counter),
+		gkit.CoalesceOnLeader(func(_ context.Context, key string) { leader <- key }),
+		gkit.CoalesceOnShared(func(_ context.Context, key string) { shared <- key }),
+	)(func(context.Context, struct{}) (struct{}, error) {
+		<-release
+		return struct{}{}, nil
+	})
+
+	ctx := gkit.WithIdempotencyKey(context.Background(), "charge-1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e(ctx, struct{}{})
+		}()
+		// Wait for each call to be accounted, so exactly one is the leader.
+		select {
+		case <-leader:
+		case <-shared:
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if want, have := uint64(1), counter.Leaders(); want != have {
+		t.Errorf("leaders: want %d, have %d", want, have)
+	}
+	if want, have := uint64(3), counter.Shared(); want != have {
+		t.Errorf("shared: want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,74 @@
+package gkit
+
+import (
+	"context"
+)
+
+type (
+	requestIDKey    struct{}
+	endpointNameKey struct{}
+	tenantKey       struct{}
+	principalKey    struct{}
+)
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being
+// processed. Transports usually set it from an incoming header.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID, or an
+// empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithEndpointName returns a copy of ctx carrying the name of the endpoint
+// being invoked.
+func WithEndpointName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, endpointNameKey{}, name)
+}
+
+// EndpointNameFromContext returns the endpoint name stored by
+// WithEndpointName, or an empty string if there is none.
+func EndpointNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(endpointNameKey{}).(string)
+	return name
+}
+
+// WithTenant returns a copy of ctx carrying the tenant the request is made on
+// behalf of.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored by WithTenant, or an empty
+// string if there is none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal,
+// e.g. a user or service account ID, the request is made by.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored by WithPrincipal, or an
+// empty string if there is none.
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// Named returns a Middleware that stores name in the context with
+// WithEndpointName before calling the next endpoint.
+func Named[Req, Res any](name string) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			return next(WithEndpointName(ctx, name), request)
+		}
+	}
+}
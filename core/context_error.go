@@ -0,0 +1,65 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ContextError annotates an error with correlation fields taken from the
+// request context. It implements slog.LogValuer, so logging it with slog
+// records the fields as structured attributes.
+type ContextError struct {
+	Err       error
+	RequestID string
+	Endpoint  string
+	Tenant    string
+}
+
+// Error implements the error interface.
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("%s (request_id=%q endpoint=%q tenant=%q)", e.Err, e.RequestID, e.Endpoint, e.Tenant)
+}
+
+// Unwrap returns the wrapped error.
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// LogValue implements slog.LogValuer.
+func (e *ContextError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("error", e.Err.Error()),
+		slog.String("request_id", e.RequestID),
+		slog.String("endpoint", e.Endpoint),
+		slog.String("tenant", e.Tenant),
+	)
+}
+
+// WrapErrorContext returns a Middleware that wraps any error returned by the
+// next endpoint in a *ContextError filled from RequestIDFromContext,
+// EndpointNameFromContext and TenantFromContext. Nil errors are returned
+// untouched, as are errors that already wrap a *ContextError.
+func WrapErrorContext[Req, Res any]() Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			response, err := next(ctx, request)
+			if err == nil {
+				return response, nil
+			}
+
+			var ce *ContextError
+			if errors.As(err, &ce) {
+				return response, err
+			}
+
+			return response, &ContextError{
+				Err:       err,
+				RequestID: RequestIDFromContext(ctx),
+				Endpoint:  EndpointNameFromContext(ctx),
+				Tenant:    TenantFromContext(ctx),
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestWrapErrorContext(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	e := gkit.Chain(
+		gkit.Named[bool, struct{}]("CreateUser"),
+		gkit.WrapErrorContext[bool, struct{}](),
+		gkit.WrapErrorContext[bool, struct{}](),
+	)(func(_ context.Context, fail bool) (struct{}, error) {
+		if fail {
+			return struct{}{}, errBoom
+		}
+		return struct{}{}, nil
+	})
+
+	ctx := gkit.WithTenant(gkit.WithRequestID(context.Background(), "a1b2"), "acme")
+
+	if _, err := e(ctx, false); err != nil {
+		t.Fatalf("want nil error untouched, have %v", err)
+	}
+
+	_, err := e(ctx, true)
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("want %v, have %v", errBoom, err)
+	}
+
+	var ce *gkit.ContextError
+	if !errors.As(err, &ce) {
+		t.Fatalf("want *ContextError, have %T", err)
+	}
+	if _, ok := ce.Err.(*gkit.ContextError); ok {
+		t.Error("error was wrapped twice")
+	}
+	if want, have := (gkit.ContextError{Err: errBoom, RequestID: "a1b2", Endpoint: "CreateUser", Tenant: "acme"}), *ce; want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
@@ -0,0 +1,108 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// TimeoutKey is the header, or metadata key, carrying the time budget left
+// to a request when its deadline is propagated across a call. It's the key
+// gRPC uses, so a gRPC peer understands it, and HTTP and JetStream calls use
+// it as a header. The value is formatted by FormatTimeout.
+const TimeoutKey = "Grpc-Timeout"
+
+// ErrInvalidTimeout is returned by ParseTimeout for a malformed value.
+var ErrInvalidTimeout = errors.New("invalid timeout")
+
+var timeoutUnits = []struct {
+	unit byte
+	d    time.Duration
+}{
+	{'n', time.Nanosecond},
+	{'u', time.Microsecond},
+	{'m', time.Millisecond},
+	{'S', time.Second},
+	{'M', time.Minute},
+	{'H', time.Hour},
+}
+
+// FormatTimeout formats d in the wire format of gRPC timeouts: at most 8
+// digits followed by a unit, one of H, M, S, m, u or n. The finest unit that
+// fits is used, rounding up, e.g. 1.5s is formatted as "1500000u". A negative
+// d is formatted as "0n", and a d too long to fit in 8 digits of hours as
+// "99999999H".
+func FormatTimeout(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	for _, u := range timeoutUnits {
+		n := d / u.d
+		if d%u.d != 0 {
+			n++
+		}
+		if n < 1e8 {
+			return strconv.FormatInt(int64(n), 10) + string(u.unit)
+		}
+	}
+	return "99999999H"
+}
+
+// ParseTimeout parses a timeout formatted by FormatTimeout. A timeout longer
+// than the longest time.Duration, e.g. "99999999H", is clamped to it.
+func ParseTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 || len(s) > 9 {
+		return 0, ErrInvalidTimeout
+	}
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidTimeout
+	}
+	for _, u := range timeoutUnits {
+		if u.unit == s[len(s)-1] {
+			if n > uint64(math.MaxInt64/u.d) {
+				return math.MaxInt64, nil
+			}
+			return time.Duration(n) * u.d, nil
+		}
+	}
+	return 0, ErrInvalidTimeout
+}
+
+type propagatedTimeoutKey struct{}
+
+// WithPropagatedTimeout returns a copy of ctx carrying the time budget a
+// caller propagated with the request. Transports store it while decoding the
+// request, and PropagateDeadline applies it.
+func WithPropagatedTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, propagatedTimeoutKey{}, d)
+}
+
+// PropagatedTimeoutFromContext returns the time budget stored by
+// WithPropagatedTimeout, if any.
+func PropagatedTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(propagatedTimeoutKey{}).(time.Duration)
+	return d, ok
+}
+
+// PropagateDeadline returns a Middleware bounding every call by the time
+// budget the caller propagated, so a service stops working on a request its
+// caller has already given up on. On the calling side, transports send the
+// remaining time of the context deadline under TimeoutKey; on the serving
+// side, they store it with WithPropagatedTimeout for this middleware. See the
+// deadline request funcs of each transport. Calls without a propagated budget
+// are left untouched.
+func PropagateDeadline[Req, Res any]() Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			if d, ok := PropagatedTimeoutFromContext(ctx); ok {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d)
+				defer cancel()
+			}
+			return next(ctx, request)
+		}
+	}
+}
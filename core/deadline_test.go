@@ -0,0 +1,66 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestTimeoutFormat(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		-time.Second:                   "0n",
+		1500 * time.Millisecond:        "1500000u",
+		90 * time.Second:               "90000000u",
+		time.Duration(99999999):        "99999999n",
+		27*time.Hour + time.Nanosecond: "97200001m",
+		math.MaxInt64:                  "2562048H",
+	} {
+		have := gkit.FormatTimeout(d)
+		if want != have {
+			t.Errorf("%v: want %q, have %q", d, want, have)
+		}
+
+		parsed, err := gkit.ParseTimeout(have)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed < d {
+			t.Errorf("%v: parsed back as the shorter %v", d, parsed)
+		}
+	}
+
+	if have, err := gkit.ParseTimeout("99999999H"); err != nil || have != math.MaxInt64 {
+		t.Errorf("99999999H: want %v, have %v, %v", time.Duration(math.MaxInt64), have, err)
+	}
+
+	for _, s := range []string{"", "5", "10x", "-1S", "123456789S"} {
+		if _, err := gkit.ParseTimeout(s); !errors.Is(err, gkit.ErrInvalidTimeout) {
+			t.Errorf("%q: want %v, have %v", s, gkit.ErrInvalidTimeout, err)
+		}
+	}
+}
+
+func TestPropagateDeadline(t *testing.T) {
+	e := gkit.PropagateDeadline[struct{}, time.Duration]()(func(ctx context.Context, _ struct{}) (time.Duration, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return 0, nil
+		}
+		return time.Until(deadline), nil
+	})
+
+	if have, _ := e(context.Background(), struct{}{}); have != 0 {
+		t.Errorf("no budget: want no deadline, have %v", have)
+	}
+
+	ctx := gkit.WithPropagatedTimeout(context.Background(), time.Second)
+	if have, _ := e(ctx, struct{}{}); have <= 0 || have > time.Second {
+		t.Errorf("budget: want deadline within 1s, have %v", have)
+	}
+}
@@ -0,0 +1,57 @@
+package gkit
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+type debugKey struct{}
+
+// WithDebug returns a copy of ctx that forces the Debug middleware to trace
+// the call, regardless of its Sampler. Transports may use it to honor a debug
+// header or flag sent by the caller.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// DebugFromContext reports whether WithDebug was applied to ctx.
+func DebugFromContext(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// Sampler decides whether a call should be traced by the Debug middleware.
+type Sampler[Req any] func(ctx context.Context, request Req) bool
+
+// SampleRate returns a Sampler that samples the given fraction of calls,
+// where 0 samples nothing and 1 samples everything.
+func SampleRate[Req any](rate float64) Sampler[Req] {
+	return func(context.Context, Req) bool {
+		return rand.Float64() < rate
+	}
+}
+
+// DebugSink receives the full request and response of a traced call, along
+// with its error and duration. Usually this means verbose logging.
+type DebugSink[Req, Res any] func(ctx context.Context, request Req, response Res, err error, took time.Duration)
+
+// Debug returns a Middleware that passes sampled calls to sink. A call is
+// sampled if the context carries WithDebug or if sample returns true. A nil
+// sample means only calls flagged with WithDebug are traced. Calls that are not
+// sampled are passed through untouched.
+func Debug[Req, Res any](sample Sampler[Req], sink DebugSink[Req, Res]) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			if !DebugFromContext(ctx) && (sample == nil || !sample(ctx, request)) {
+				return next(ctx, request)
+			}
+
+			begin := time.Now()
+			response, err := next(ctx, request)
+			sink(ctx, request, response, err, time.Since(begin))
+
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestDebug(t *testing.T) {
+	var traced []string
+	sink := func(_ context.Context, request string, response string, _ error, _ time.Duration) {
+		traced = append(traced, request+":"+response)
+	}
+	echo := func(_ context.Context, request string) (string, error) { return request, nil }
+
+	never := gkit.Debug[string, string](gkit.SampleRate[string](0), sink)(echo)
+	if _, err := never(context.Background(), "quiet"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := never(gkit.WithDebug(context.Background()), "flagged"); err != nil {
+		t.Fatal(err)
+	}
+
+	always := gkit.Debug[string, string](gkit.SampleRate[string](1), sink)(echo)
+	if _, err := always(context.Background(), "sampled"); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := []string{"flagged:flagged", "sampled:sampled"}, traced; len(want) != len(have) || want[0] != have[0] || want[1] != have[1] {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,40 @@
+package gkit
+
+import (
+	"context"
+)
+
+// detachedKeys are the keys of the correlation values carried by
+// DetachContext.
+var detachedKeys = []any{
+	requestIDKey{},
+	endpointNameKey{},
+	tenantKey{},
+	principalKey{},
+	fieldsKey{},
+	loggerKey{},
+	baggageKey{},
+	debugKey{},
+}
+
+// DetachContext returns a context for background work spawned by an endpoint,
+// e.g. a goroutine sending a notification once the response is written. It's
+// never canceled and has no deadline, so the work outlives the request, but
+// keeps the values correlating it with the request: the request ID, endpoint
+// name, tenant, principal, fields, logger, baggage and debug flag of ctx.
+// Other values, such as the idempotency key or a propagated timeout, aren't
+// carried, unless their keys are given, e.g. the key of a tracing span.
+//
+// Unlike context.WithoutCancel, the request-scoped values of transports and
+// other packages aren't kept alive by the detached context.
+func DetachContext(ctx context.Context, keys ...any) context.Context {
+	detached := context.Background()
+	for _, ks := range [][]any{detachedKeys, keys} {
+		for _, k := range ks {
+			if v := ctx.Value(k); v != nil {
+				detached = context.WithValue(detached, k, v)
+			}
+		}
+	}
+	return detached
+}
@@ -0,0 +1,49 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type traceKey struct{}
+
+func TestDetachContext(t *testing.T) {
+	logger := slog.New(slog.Default().Handler())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx = gkit.WithRequestID(ctx, "req-1")
+	ctx = gkit.WithTenant(ctx, "acme")
+	ctx = gkit.WithLogger(ctx, logger)
+	ctx = gkit.WithIdempotencyKey(ctx, "key")
+	ctx = context.WithValue(ctx, traceKey{}, "span")
+	cancel()
+
+	detached := gkit.DetachContext(ctx, traceKey{})
+	if err := detached.Err(); err != nil {
+		t.Errorf("want a live context, have %v", err)
+	}
+	if _, ok := detached.Deadline(); ok {
+		t.Error("want no deadline")
+	}
+	if want, have := "req-1", gkit.RequestIDFromContext(detached); want != have {
+		t.Errorf("request ID: want %q, have %q", want, have)
+	}
+	if want, have := "acme", gkit.TenantFromContext(detached); want != have {
+		t.Errorf("tenant: want %q, have %q", want, have)
+	}
+	if gkit.LoggerFromContext(detached) != logger {
+		t.Error("logger wasn't carried")
+	}
+	if want, have := "span", detached.Value(traceKey{}); want != have {
+		t.Errorf("extra key: want %q, have %v", want, have)
+	}
+	if have := gkit.IdempotencyKeyFromContext(detached); have != "" {
+		t.Errorf("idempotency key: want none, have %q", have)
+	}
+}
@@ -2,6 +2,7 @@ package gkit
 
 import (
 	"context"
+	"encoding/json"
 )
 
 // EncodeDecodeFunc encodes or decodes a user-domain object from a payload.
@@ -32,3 +33,20 @@ func NopResponseEncoder[Res, RespWriter any](context.Context, RespWriter, Res) e
 // NopErrorEncoder does nothing.
 func NopErrorEncoder[RespWriter any](context.Context, RespWriter, error) {
 }
+
+// JSONDecoderOption configures the json.Decoder used by the JSON decode
+// helpers of the transports.
+type JSONDecoderOption func(*json.Decoder)
+
+// UseNumber makes the decoder unmarshal numbers into any or map fields as
+// json.Number instead of float64, so large integers and precise decimals are
+// not rounded. Fields with a concrete numeric type are unaffected.
+func UseNumber(dec *json.Decoder) {
+	dec.UseNumber()
+}
+
+// DisallowUnknownFields makes the decoder fail when the payload contains a
+// field that doesn't match any field of the destination struct.
+func DisallowUnknownFields(dec *json.Decoder) {
+	dec.DisallowUnknownFields()
+}
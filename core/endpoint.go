@@ -13,3 +13,21 @@ func NopEndpoint[Req, Res any](context.Context, Req) (Res, error) {
 	var res Res
 	return res, nil
 }
+
+// Middleware is a chainable behavior modifier for endpoints. Since servers
+// wrap a business Endpoint and clients produce an Endpoint calling the remote
+// service, the same Middleware can be applied on either side: around the
+// Endpoint given to a server, or around the one returned by a client.
+type Middleware[Req, Res any] func(Endpoint[Req, Res]) Endpoint[Req, Res]
+
+// Chain is a helper function for composing middlewares. Requests will
+// traverse them in the order they're declared. That is, the first middleware
+// is treated as the outermost middleware.
+func Chain[Req, Res any](outer Middleware[Req, Res], others ...Middleware[Req, Res]) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		for i := len(others) - 1; i >= 0; i-- {
+			next = others[i](next)
+		}
+		return outer(next)
+	}
+}
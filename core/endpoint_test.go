@@ -0,0 +1,46 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"fmt"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func ExampleChain() {
+	e := gkit.Chain(
+		annotate("first"),
+		annotate("second"),
+		annotate("third"),
+	)(myEndpoint)
+
+	if _, err := e(context.Background(), struct{}{}); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// first pre
+	// second pre
+	// third pre
+	// my endpoint!
+	// third post
+	// second post
+	// first post
+}
+
+func annotate(s string) gkit.Middleware[struct{}, struct{}] {
+	return func(next gkit.Endpoint[struct{}, struct{}]) gkit.Endpoint[struct{}, struct{}] {
+		return func(ctx context.Context, request struct{}) (struct{}, error) {
+			fmt.Println(s, "pre")
+			defer fmt.Println(s, "post")
+			return next(ctx, request)
+		}
+	}
+}
+
+func myEndpoint(context.Context, struct{}) (struct{}, error) {
+	fmt.Println("my endpoint!")
+	return struct{}{}, nil
+}
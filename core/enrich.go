@@ -0,0 +1,24 @@
+package gkit
+
+import (
+	"context"
+)
+
+// Enrich returns a Middleware that calls fn before the next endpoint to load
+// data referenced by the request, e.g. the user behind an ID, and attach it
+// to the context fn returns. If fn fails, its error is returned and next is
+// not called, so fn can short-circuit with an error a transport maps to 404
+// or 401. Placed after an authentication middleware, fn can rely on the
+// identity it stored in the context.
+func Enrich[Req, Res any](fn func(ctx context.Context, request Req) (context.Context, error)) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			ctx, err := fn(ctx, request)
+			if err != nil {
+				var res Res
+				return res, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type userKey struct{}
+
+func TestEnrich(t *testing.T) {
+	users := map[int]string{1: "ann"}
+	errNoUser := errors.New("no such user")
+
+	load := gkit.Enrich[int, string](func(ctx context.Context, id int) (context.Context, error) {
+		name, ok := users[id]
+		if !ok {
+			return ctx, errNoUser
+		}
+		return context.WithValue(ctx, userKey{}, name), nil
+	})
+
+	var calls int
+	e := load(func(ctx context.Context, _ int) (string, error) {
+		calls++
+		return ctx.Value(userKey{}).(string), nil
+	})
+
+	have, err := e(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ann"; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if _, err := e(context.Background(), 2); !errors.Is(err, errNoUser) {
+		t.Errorf("want %v, have %v", errNoUser, err)
+	}
+	if want := 1; want != calls {
+		t.Errorf("want %d endpoint call, have %d", want, calls)
+	}
+}
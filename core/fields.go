@@ -0,0 +1,55 @@
+package gkit
+
+import (
+	"context"
+)
+
+// FieldExtractor returns the fields of a request worth recording by logging,
+// metrics and tracing middlewares, as alternating keys and values or
+// slog.Attr values, in the style of slog.Logger.With. Defining it once per
+// endpoint keeps every observability middleware recording the same fields,
+// and keeps fields that must not be recorded, such as personal data, out of
+// all of them.
+type FieldExtractor[Req any] func(request Req) []any
+
+// NoFields is the default FieldExtractor, which extracts nothing.
+func NoFields[Req any](Req) []any {
+	return nil
+}
+
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying fields in addition to those
+// already stored by WithFields.
+func WithFields(ctx context.Context, fields ...any) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	existing := FieldsFromContext(ctx)
+	all := make([]any, 0, len(existing)+len(fields))
+	all = append(append(all, existing...), fields...)
+	return context.WithValue(ctx, fieldsKey{}, all)
+}
+
+// FieldsFromContext returns the fields stored by WithFields, or nil if there
+// are none.
+func FieldsFromContext(ctx context.Context) []any {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	return fields
+}
+
+// ExtractFields returns a Middleware storing the fields returned by extract
+// in the context with WithFields, where InjectLogger and other observability
+// middlewares placed after it pick them up through FieldsFromContext. A nil
+// extract means NoFields.
+func ExtractFields[Req, Res any](extract FieldExtractor[Req]) Middleware[Req, Res] {
+	if extract == nil {
+		extract = NoFields[Req]
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			return next(WithFields(ctx, extract(request)...), request)
+		}
+	}
+}
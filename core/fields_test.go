@@ -0,0 +1,60 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type login struct {
+	User     string
+	Password string
+}
+
+func TestExtractFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	extract := func(l login) []any { return []any{"user", l.User} }
+
+	var sunk []any
+	e := gkit.Chain(
+		gkit.ExtractFields[login, struct{}](extract),
+		gkit.InjectLogger[login, struct{}](logger),
+		gkit.Debug[login, struct{}](nil, func(ctx context.Context, _ login, _ struct{}, _ error, _ time.Duration) {
+			sunk = gkit.FieldsFromContext(ctx)
+		}),
+	)(func(ctx context.Context, _ login) (struct{}, error) {
+		gkit.LoggerFromContext(ctx).Info("logged in")
+		return struct{}{}, nil
+	})
+
+	e(gkit.WithDebug(context.Background()), login{User: "ann", Password: "hunter2"})
+
+	if !strings.Contains(buf.String(), "user=ann") {
+		t.Errorf("want user=ann in %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("want password left out, have %q", buf.String())
+	}
+	if len(sunk) != 2 || sunk[1] != "ann" {
+		t.Errorf("debug sink: want the extracted fields, have %v", sunk)
+	}
+}
+
+func TestExtractFieldsDefault(t *testing.T) {
+	e := gkit.ExtractFields[login, []any](nil)(func(ctx context.Context, _ login) ([]any, error) {
+		return gkit.FieldsFromContext(ctx), nil
+	})
+
+	if have, _ := e(context.Background(), login{User: "ann"}); have != nil {
+		t.Errorf("want no fields, have %v", have)
+	}
+}
@@ -0,0 +1,76 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStale is returned by a Freshness middleware when the response is older
+// than the maximum age, even after refetching it.
+var ErrStale = errors.New("response is stale")
+
+// Timestamper is implemented by responses that know when their data was
+// produced, e.g. from a Last-Modified header or an updated_at field.
+type Timestamper interface {
+	Timestamp() time.Time
+}
+
+// ResponseTimestamp is the default timestamp func of Freshness: it returns
+// the timestamp of responses implementing Timestamper.
+func ResponseTimestamp[Res any](response Res) (time.Time, bool) {
+	if ts, ok := any(response).(Timestamper); ok {
+		return ts.Timestamp(), true
+	}
+	return time.Time{}, false
+}
+
+type noCacheKey struct{}
+
+// WithNoCache returns a copy of ctx asking the transports to bypass
+// intermediate caches, e.g. with a "Cache-Control: no-cache" header.
+func WithNoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// NoCacheFromContext reports whether WithNoCache was used on ctx.
+func NoCacheFromContext(ctx context.Context) bool {
+	noCache, _ := ctx.Value(noCacheKey{}).(bool)
+	return noCache
+}
+
+// Freshness returns a Middleware guaranteeing that responses are at most
+// maxAge old, according to timestamp, which defaults to ResponseTimestamp when
+// nil. Responses without a timestamp are passed through. A stale response is
+// either rejected with ErrStale or, if refetch is true, fetched once more with
+// a context marked by WithNoCache, so that caches between the client and the
+// origin are bypassed; if that response is still stale, ErrStale is returned.
+func Freshness[Req, Res any](maxAge time.Duration, timestamp func(Res) (time.Time, bool), refetch bool) Middleware[Req, Res] {
+	if timestamp == nil {
+		timestamp = ResponseTimestamp[Res]
+	}
+
+	fresh := func(res Res) bool {
+		ts, ok := timestamp(res)
+		return !ok || time.Since(ts) <= maxAge
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			res, err := next(ctx, request)
+			if err != nil || fresh(res) {
+				return res, err
+			}
+
+			if refetch {
+				res, err = next(WithNoCache(ctx), request)
+				if err != nil || fresh(res) {
+					return res, err
+				}
+			}
+
+			var zero Res
+			return zero, ErrStale
+		}
+	}
+}
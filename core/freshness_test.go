@@ -0,0 +1,67 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type quote struct {
+	updated time.Time
+}
+
+func (q quote) Timestamp() time.Time { return q.updated }
+
+func TestFreshness(t *testing.T) {
+	var (
+		cached = quote{updated: time.Now().Add(-time.Hour)}
+		origin = quote{updated: time.Now()}
+		calls  int
+	)
+	// The cached copy is served unless the caches are bypassed.
+	next := func(ctx context.Context, _ struct{}) (quote, error) {
+		calls++
+		if gkit.NoCacheFromContext(ctx) {
+			return origin, nil
+		}
+		return cached, nil
+	}
+
+	e := gkit.Freshness[struct{}, quote](time.Minute, nil, false)(next)
+	if _, err := e(context.Background(), struct{}{}); !errors.Is(err, gkit.ErrStale) {
+		t.Errorf("without refetch: want %v, have %v", gkit.ErrStale, err)
+	}
+
+	calls = 0
+	e = gkit.Freshness[struct{}, quote](time.Minute, nil, true)(next)
+	have, err := e(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have != origin {
+		t.Errorf("with refetch: want the origin response, have %v", have)
+	}
+	if want := 2; want != calls {
+		t.Errorf("want %d calls, have %d", want, calls)
+	}
+}
+
+func TestFreshnessCustomTimestamp(t *testing.T) {
+	timestamp := func(ts time.Time) (time.Time, bool) { return ts, !ts.IsZero() }
+	e := gkit.Freshness[time.Time, time.Time](time.Minute, timestamp, false)(gkit.PassThroughEncoderDecoder[time.Time])
+
+	for ts, want := range map[time.Time]error{
+		{}:                               nil,
+		time.Now():                       nil,
+		time.Now().Add(-2 * time.Minute): gkit.ErrStale,
+	} {
+		if _, err := e(context.Background(), ts); !errors.Is(err, want) {
+			t.Errorf("%v: want %v, have %v", ts, want, err)
+		}
+	}
+}
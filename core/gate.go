@@ -0,0 +1,32 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDisabled is returned by a gated endpoint when the gate is closed and no
+// alternate endpoint was given.
+var ErrDisabled = errors.New("endpoint disabled")
+
+// Gate returns a Middleware that only calls the next endpoint when enabled
+// returns true, e.g. when a feature flag carried in the context is on.
+// Otherwise the call is routed to whenDisabled, which when nil defaults to an
+// endpoint that returns ErrDisabled.
+func Gate[Req, Res any](enabled func(ctx context.Context, request Req) bool, whenDisabled Endpoint[Req, Res]) Middleware[Req, Res] {
+	if whenDisabled == nil {
+		whenDisabled = func(context.Context, Req) (Res, error) {
+			var res Res
+			return res, ErrDisabled
+		}
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			if !enabled(ctx, request) {
+				return whenDisabled(ctx, request)
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestGate(t *testing.T) {
+	var (
+		next     = func(context.Context, bool) (string, error) { return "next", nil }
+		fallback = func(context.Context, bool) (string, error) { return "fallback", nil }
+		enabled  = func(_ context.Context, on bool) bool { return on }
+	)
+
+	e := gkit.Gate(enabled, fallback)(next)
+	for on, want := range map[bool]string{true: "next", false: "fallback"} {
+		have, err := e(context.Background(), on)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != have {
+			t.Errorf("enabled %v: want %q, have %q", on, want, have)
+		}
+	}
+
+	e = gkit.Gate[bool, string](enabled, nil)(next)
+	if _, err := e(context.Background(), false); !errors.Is(err, gkit.ErrDisabled) {
+		t.Errorf("want %v, have %v", gkit.ErrDisabled, err)
+	}
+}
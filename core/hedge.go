@@ -0,0 +1,148 @@
+package gkit
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Hedger decides when a hedged request is sent. It keeps a rolling window of
+// recent latencies and sends the backup request once the first one has been
+// outstanding for longer than the configured percentile of that window. A
+// Hedger may be shared by several endpoints calling the same backend.
+type Hedger struct {
+	percentile float64
+	delay      time.Duration
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	full      bool
+}
+
+// NewHedger returns a Hedger that hedges at the 95th percentile of the last
+// 100 calls, and after 100ms until the first call completes.
+func NewHedger(options ...Option[*Hedger]) *Hedger {
+	h := &Hedger{
+		percentile: 0.95,
+		delay:      100 * time.Millisecond,
+		latencies:  make([]time.Duration, 100),
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// HedgePercentile sets the latency percentile, a fraction in (0, 1], after
+// which the backup request is sent, e.g. 0.95. Values above 1, e.g. a percent
+// value such as 95, are lowered to 1, and values of 0 or less are raised to
+// the lowest latency of the window.
+func HedgePercentile(p float64) Option[*Hedger] {
+	switch {
+	case p > 1:
+		p = 1
+	case !(p > 0):
+		p = math.SmallestNonzeroFloat64
+	}
+	return func(h *Hedger) { h.percentile = p }
+}
+
+// HedgeWindow sets the number of recent calls the percentile is computed on.
+// Sizes below 1 are raised to 1.
+func HedgeWindow(size int) Option[*Hedger] {
+	if size < 1 {
+		size = 1
+	}
+	return func(h *Hedger) { h.latencies = make([]time.Duration, size) }
+}
+
+// HedgeInitialDelay sets the delay used until a latency has been observed.
+func HedgeInitialDelay(d time.Duration) Option[*Hedger] {
+	return func(h *Hedger) { h.delay = d }
+}
+
+// Delay returns how long a call is currently given before it is hedged.
+func (h *Hedger) Delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.next
+	if h.full {
+		n = len(h.latencies)
+	}
+	if n == 0 {
+		return h.delay
+	}
+
+	window := append([]time.Duration(nil), h.latencies[:n]...)
+	sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+
+	i := int(math.Ceil(h.percentile*float64(n))) - 1
+	return window[min(max(i, 0), n-1)]
+}
+
+// Observe records the latency of a call in the window. Hedge calls it for
+// every successful call; call it to seed the window, e.g. with the latencies
+// of a previous run.
+func (h *Hedger) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.latencies[h.next] = d
+	h.next++
+	if h.next == len(h.latencies) {
+		h.next, h.full = 0, true
+	}
+}
+
+type hedgeResult[Res any] struct {
+	res Res
+	err error
+}
+
+// Hedge returns a Middleware that sends a second, identical call to the next
+// endpoint when the first one is slower than h.Delay(). The first successful
+// response wins and the context of the other call is canceled. If the first
+// call fails before the delay, its error is returned without hedging; if both
+// calls fail, the last error is returned. Only use it for requests that are
+// safe to send twice.
+func Hedge[Req, Res any](h *Hedger) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			results := make(chan hedgeResult[Res], 2)
+			call := func() {
+				begin := time.Now()
+				res, err := next(ctx, request)
+				if err == nil {
+					h.Observe(time.Since(begin))
+				}
+				results <- hedgeResult[Res]{res, err}
+			}
+
+			timer := time.NewTimer(h.Delay())
+			defer timer.Stop()
+
+			go call()
+			sent, received := 1, 0
+
+			for {
+				select {
+				case <-timer.C:
+					go call()
+					sent++
+				case r := <-results:
+					received++
+					if r.err == nil || received == sent {
+						return r.res, r.err
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestHedgerDelayTracksPercentile(t *testing.T) {
+	h := gkit.NewHedger(gkit.HedgePercentile(0.5), gkit.HedgeWindow(4), gkit.HedgeInitialDelay(time.Second))
+	if want, have := time.Second, h.Delay(); want != have {
+		t.Errorf("initial delay: want %v, have %v", want, have)
+	}
+
+	for _, d := range []time.Duration{90, 40, 30, 20, 10} {
+		h.Observe(d * time.Millisecond)
+	}
+
+	// The window holds the last 4 latencies, 40, 30, 20 and 10ms.
+	if want, have := 20*time.Millisecond, h.Delay(); want != have {
+		t.Errorf("p50 delay: want %v, have %v", want, have)
+	}
+}
+
+func TestHedgeWindowTooSmall(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		h := gkit.NewHedger(gkit.HedgeWindow(size))
+		h.Observe(time.Millisecond)
+		h.Observe(2 * time.Millisecond)
+		if want, have := 2*time.Millisecond, h.Delay(); want != have {
+			t.Errorf("size %d: want %v, have %v", size, want, have)
+		}
+	}
+}
+
+func TestHedgePercentileOutOfRange(t *testing.T) {
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{95, 3 * time.Millisecond},
+		{1, 3 * time.Millisecond},
+		{0, time.Millisecond},
+		{-1, time.Millisecond},
+		{math.NaN(), time.Millisecond},
+	} {
+		p, want := tc.p, tc.want
+		h := gkit.NewHedger(gkit.HedgePercentile(p))
+		for _, d := range []time.Duration{3 * time.Millisecond, time.Millisecond, 2 * time.Millisecond} {
+			h.Observe(d)
+		}
+		if have := h.Delay(); want != have {
+			t.Errorf("percentile %v: want %v, have %v", p, want, have)
+		}
+	}
+}
+
+func TestHedgeSendsBackup(t *testing.T) {
+	var calls int32
+
+	h := gkit.NewHedger(gkit.HedgeInitialDelay(time.Nanosecond))
+	e := gkit.Hedge[struct{}, int32](h)(func(ctx context.Context, _ struct{}) (int32, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return n, nil
+	})
+
+	res, err := e(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int32(2), res; want != have {
+		t.Errorf("want response of backup call %d, have %d", want, have)
+	}
+}
+
+func TestHedgeFastCallNotHedged(t *testing.T) {
+	var calls int32
+
+	h := gkit.NewHedger(gkit.HedgeInitialDelay(time.Hour))
+	e := gkit.Hedge[struct{}, struct{}](h)(func(context.Context, struct{}) (struct{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return struct{}{}, nil
+	})
+
+	if _, err := e(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int32(1), atomic.LoadInt32(&calls); want != have {
+		t.Errorf("want %d call, have %d", want, have)
+	}
+}
@@ -0,0 +1,89 @@
+package lb
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// LookupSRVFunc resolves SRV records, with the signature of net.LookupSRV.
+type LookupSRVFunc func(service, proto, name string) (cname string, addrs []*net.SRV, err error)
+
+// DNSSRVInstancer is an Instancer resolving the SRV records of a name, such
+// as the headless service of a Kubernetes deployment, every TTL. Instances
+// are reported as "host:port". The priority and weight of the records are
+// ignored: every instance gets an equal share of the traffic. Build a
+// Weighted balancer from a lookup of its own to honor the weights.
+type DNSSRVInstancer struct {
+	registry
+
+	name   string
+	ttl    time.Duration
+	lookup LookupSRVFunc
+	quit   chan struct{}
+	stop   sync.Once
+}
+
+// DNSSRVLookup sets the function resolving SRV records. Defaults to
+// net.LookupSRV.
+func DNSSRVLookup(lookup LookupSRVFunc) gkit.Option[*DNSSRVInstancer] {
+	return func(i *DNSSRVInstancer) { i.lookup = lookup }
+}
+
+// NewDNSSRVInstancer returns a DNSSRVInstancer resolving name, e.g.
+// "_http._tcp.audit.default.svc.cluster.local", right away and then every
+// ttl, until Stop is called.
+func NewDNSSRVInstancer(name string, ttl time.Duration, options ...gkit.Option[*DNSSRVInstancer]) *DNSSRVInstancer {
+	i := &DNSSRVInstancer{
+		name:   name,
+		ttl:    ttl,
+		lookup: net.LookupSRV,
+		quit:   make(chan struct{}),
+	}
+	for _, option := range options {
+		option(i)
+	}
+
+	i.resolve()
+	go i.loop()
+	return i
+}
+
+func (i *DNSSRVInstancer) loop() {
+	t := time.NewTicker(i.ttl)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			i.resolve()
+		case <-i.quit:
+			return
+		}
+	}
+}
+
+func (i *DNSSRVInstancer) resolve() {
+	_, addrs, err := i.lookup("", "", i.name)
+	if err != nil {
+		i.broadcast(Event{Err: err})
+		return
+	}
+
+	instances := make([]string, len(addrs))
+	for j, addr := range addrs {
+		host := strings.TrimSuffix(addr.Target, ".")
+		instances[j] = net.JoinHostPort(host, strconv.Itoa(int(addr.Port)))
+	}
+	i.broadcast(Event{Instances: instances})
+}
+
+// Stop implements Instancer. It stops resolving the name. It may be called
+// more than once.
+func (i *DNSSRVInstancer) Stop() {
+	i.stop.Do(func() { close(i.quit) })
+}
@@ -0,0 +1,221 @@
+package lb
+
+import (
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// Event is the state of a service discovery source: the instances currently
+// registered, e.g. "10.0.0.1:8080", or the error that prevented resolving
+// them.
+type Event struct {
+	Instances []string
+	Err       error
+}
+
+// Instancer watches a service discovery source, such as DNS SRV records or
+// Consul, and notifies registered channels whenever the set of instances
+// changes. A newly registered channel immediately receives the current state.
+// Receivers are expected to drain their channel promptly.
+type Instancer interface {
+	Register(ch chan<- Event)
+	Deregister(ch chan<- Event)
+	Stop()
+}
+
+// Factory builds the endpoint calling one instance, e.g. an HTTP Client
+// targeting it. The returned io.Closer, if not nil, is closed when the
+// instance goes away.
+type Factory[Req, Res any] func(instance string) (gkit.Endpoint[Req, Res], io.Closer, error)
+
+// Endpointer provides the endpoints currently available to a Balancer.
+type Endpointer[Req, Res any] interface {
+	Endpoints() ([]gkit.Endpoint[Req, Res], error)
+}
+
+// FixedEndpointer is an Endpointer over a static set of endpoints.
+type FixedEndpointer[Req, Res any] []gkit.Endpoint[Req, Res]
+
+// Endpoints implements Endpointer.
+func (s FixedEndpointer[Req, Res]) Endpoints() ([]gkit.Endpoint[Req, Res], error) {
+	return s, nil
+}
+
+type subscribed[Req, Res any] struct {
+	endpoint gkit.Endpoint[Req, Res]
+	closer   io.Closer
+}
+
+// Subscriber is an Endpointer kept up to date with the instances reported by
+// an Instancer: an endpoint is built with the Factory when an instance
+// appears, and closed when it disappears. When the Instancer reports an
+// error, the last known endpoints are kept, so a flapping discovery source
+// doesn't take the service down; the error is only returned when there are
+// no endpoints at all. An instance the Factory fails to build an endpoint for
+// is logged and left out until the set of instances changes; when no
+// endpoint is left, Endpoints returns the Factory error.
+type Subscriber[Req, Res any] struct {
+	src     Instancer
+	factory Factory[Req, Res]
+	events  chan Event
+	done    chan struct{}
+
+	mu        sync.RWMutex
+	instances map[string]subscribed[Req, Res]
+	endpoints []gkit.Endpoint[Req, Res]
+	err       error
+}
+
+// NewSubscriber returns a Subscriber registered with src. Call Close to
+// deregister it and close the endpoints it built.
+func NewSubscriber[Req, Res any](src Instancer, factory Factory[Req, Res]) *Subscriber[Req, Res] {
+	s := &Subscriber[Req, Res]{
+		src:       src,
+		factory:   factory,
+		events:    make(chan Event),
+		done:      make(chan struct{}),
+		instances: make(map[string]subscribed[Req, Res]),
+	}
+	go s.receive()
+	src.Register(s.events)
+	return s
+}
+
+func (s *Subscriber[Req, Res]) receive() {
+	defer close(s.done)
+	for event := range s.events {
+		s.update(event)
+	}
+}
+
+func (s *Subscriber[Req, Res]) update(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.Err != nil {
+		s.err = event.Err
+		return
+	}
+
+	var (
+		seen       = make(map[string]bool, len(event.Instances))
+		factoryErr error
+	)
+	for _, instance := range event.Instances {
+		seen[instance] = true
+		if _, ok := s.instances[instance]; ok {
+			continue
+		}
+		e, closer, err := s.factory(instance)
+		if err != nil {
+			slog.Warn("lb: building the endpoint of an instance failed",
+				slog.String("instance", instance), slog.Any("error", err))
+			factoryErr = err
+			continue
+		}
+		s.instances[instance] = subscribed[Req, Res]{endpoint: e, closer: closer}
+	}
+	for instance, sub := range s.instances {
+		if seen[instance] {
+			continue
+		}
+		if sub.closer != nil {
+			sub.closer.Close() //nolint:errcheck
+		}
+		delete(s.instances, instance)
+	}
+
+	names := make([]string, 0, len(s.instances))
+	for instance := range s.instances {
+		names = append(names, instance)
+	}
+	sort.Strings(names)
+
+	s.endpoints = make([]gkit.Endpoint[Req, Res], len(names))
+	for i, instance := range names {
+		s.endpoints[i] = s.instances[instance].endpoint
+	}
+	s.err = factoryErr
+}
+
+// Endpoints implements Endpointer. Endpoints are ordered by instance.
+func (s *Subscriber[Req, Res]) Endpoints() ([]gkit.Endpoint[Req, Res], error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.endpoints) == 0 && s.err != nil {
+		return nil, s.err
+	}
+	return s.endpoints, nil
+}
+
+// Close deregisters the Subscriber from its Instancer and closes the
+// endpoints it built. It doesn't stop the Instancer, which may be shared.
+func (s *Subscriber[Req, Res]) Close() {
+	s.src.Deregister(s.events)
+	close(s.events)
+	<-s.done
+
+	s.update(Event{})
+}
+
+// registry keeps the current Event of an Instancer and broadcasts changes to
+// the registered channels.
+type registry struct {
+	mu    sync.Mutex
+	state Event
+	chans map[chan<- Event]struct{}
+}
+
+func (r *registry) Register(ch chan<- Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.chans == nil {
+		r.chans = make(map[chan<- Event]struct{})
+	}
+	r.chans[ch] = struct{}{}
+	ch <- r.state
+}
+
+func (r *registry) Deregister(ch chan<- Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.chans, ch)
+}
+
+// broadcast records event as the current state and sends it to every
+// registered channel, unless it's identical to the current state.
+func (r *registry) broadcast(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sort.Strings(event.Instances)
+	if equalEvents(r.state, event) {
+		return
+	}
+	r.state = event
+	for ch := range r.chans {
+		ch <- event
+	}
+}
+
+func equalEvents(a, b Event) bool {
+	if (a.Err == nil) != (b.Err == nil) || a.Err != nil && a.Err.Error() != b.Err.Error() {
+		return false
+	}
+	if len(a.Instances) != len(b.Instances) {
+		return false
+	}
+	for i := range a.Instances {
+		if a.Instances[i] != b.Instances[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+//go:build unit
+
+package lb_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"github.com/bobobox-id/gkit/core/lb"
+)
+
+type closer struct {
+	mu     *sync.Mutex
+	closed map[string]bool
+	name   string
+}
+
+func (c closer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed[c.name] = true
+	return nil
+}
+
+// eventually polls cond until it holds or a second has passed.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscriberFollowsDNSSRV(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		records = []*net.SRV{{Target: "a.local.", Port: 80}, {Target: "b.local.", Port: 80}}
+		err     error
+		closed  = map[string]bool{}
+	)
+	lookup := func(_, _, name string) (string, []*net.SRV, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return name, records, err
+	}
+
+	instancer := lb.NewDNSSRVInstancer("_http._tcp.svc.local", 5*time.Millisecond, lb.DNSSRVLookup(lookup))
+	defer instancer.Stop()
+
+	factory := func(instance string) (gkit.Endpoint[struct{}, string], io.Closer, error) {
+		return named(instance), closer{mu: &mu, closed: closed, name: instance}, nil
+	}
+	s := lb.NewSubscriber[struct{}, string](instancer, factory)
+	defer s.Close()
+
+	b := lb.NewRoundRobin[struct{}, string](s)
+	have := func() string {
+		endpoints, err := s.Endpoints()
+		if err != nil {
+			return err.Error()
+		}
+		return strings.Join(pick(t, b, len(endpoints)), " ")
+	}
+
+	eventually(t, func() bool { return have() == "a.local:80 b.local:80" })
+
+	mu.Lock()
+	records = []*net.SRV{{Target: "b.local.", Port: 80}, {Target: "c.local.", Port: 8080}}
+	mu.Unlock()
+	eventually(t, func() bool { return have() == "b.local:80 c.local:8080" })
+
+	mu.Lock()
+	if !closed["a.local:80"] {
+		t.Error("a.local:80 was not closed when it disappeared")
+	}
+	err = errors.New("SERVFAIL")
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	if want := "b.local:80 c.local:8080"; want != have() {
+		t.Errorf("on lookup error: want last endpoints %q, have %q", want, have())
+	}
+}
+
+func TestSubscriberFactoryError(t *testing.T) {
+	errFactory := errors.New("bad instance")
+	instancer := lb.NewDNSSRVInstancer("_http._tcp.svc.local", time.Hour, lb.DNSSRVLookup(func(_, _, name string) (string, []*net.SRV, error) {
+		return name, []*net.SRV{{Target: "a.local.", Port: 80}}, nil
+	}))
+	defer instancer.Stop()
+	defer instancer.Stop()
+
+	s := lb.NewSubscriber[struct{}, string](instancer, func(string) (gkit.Endpoint[struct{}, string], io.Closer, error) {
+		return nil, nil, errFactory
+	})
+	defer s.Close()
+
+	eventually(t, func() bool {
+		_, err := s.Endpoints()
+		return errors.Is(err, errFactory)
+	})
+}
+
+func TestRoundRobinNoEndpoints(t *testing.T) {
+	b := lb.NewRoundRobin[struct{}, string](lb.FixedEndpointer[struct{}, string]{})
+	if _, err := b.Endpoint(); !errors.Is(err, lb.ErrNoEndpoints) {
+		t.Errorf("want %v, have %v", lb.ErrNoEndpoints, err)
+	}
+}
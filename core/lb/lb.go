@@ -0,0 +1,31 @@
+// Package lb provides load balancers that pick one of several endpoints, e.g.
+// clients for each instance of a remote service, for every call.
+package lb
+
+import (
+	"context"
+	"errors"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// ErrNoEndpoints is returned by a Balancer that has no endpoint to pick.
+var ErrNoEndpoints = errors.New("no endpoints available")
+
+// Balancer yields an endpoint according to some heuristic.
+type Balancer[Req, Res any] interface {
+	Endpoint() (gkit.Endpoint[Req, Res], error)
+}
+
+// Balanced returns an Endpoint that asks b for an endpoint on every call and
+// invokes it. Wrap it with gkit.Retry to try another endpoint when one fails.
+func Balanced[Req, Res any](b Balancer[Req, Res]) gkit.Endpoint[Req, Res] {
+	return func(ctx context.Context, request Req) (Res, error) {
+		e, err := b.Endpoint()
+		if err != nil {
+			var res Res
+			return res, err
+		}
+		return e(ctx, request)
+	}
+}
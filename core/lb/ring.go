@@ -0,0 +1,120 @@
+package lb
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// DefaultReplicas is the number of points each instance is given on a Ring
+// when no positive replica count is set.
+const DefaultReplicas = 100
+
+type ringPoint struct {
+	hash     uint64
+	instance string
+}
+
+// Ring routes requests with consistent hashing: all requests with the same
+// key go to the same instance, for cache affinity or sticky sessions, and
+// when an instance is added or removed only the keys it owns move. Each
+// instance is placed at several points on the ring, its replicas, to spread
+// keys evenly. It is safe for concurrent use.
+type Ring[Req, Res any] struct {
+	key      func(ctx context.Context, request Req) string
+	replicas int
+
+	mu        sync.RWMutex
+	points    []ringPoint
+	endpoints map[string]gkit.Endpoint[Req, Res]
+}
+
+// NewRing returns an empty Ring hashing the key returned by key, with
+// replicas points per instance, or DefaultReplicas if replicas is not
+// positive. Call Update to add instances.
+func NewRing[Req, Res any](key func(ctx context.Context, request Req) string, replicas int) *Ring[Req, Res] {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	return &Ring[Req, Res]{key: key, replicas: replicas}
+}
+
+// Update replaces the instances of the ring, each identified by a stable
+// name such as its address.
+func (r *Ring[Req, Res]) Update(endpoints map[string]gkit.Endpoint[Req, Res]) {
+	points := make([]ringPoint, 0, len(endpoints)*r.replicas)
+	for instance := range endpoints {
+		for i := 0; i < r.replicas; i++ {
+			points = append(points, ringPoint{hash: hash(instance + "#" + strconv.Itoa(i)), instance: instance})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].instance < points[j].instance
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.points, r.endpoints = points, endpoints
+}
+
+// Instance returns the instance owning key.
+func (r *Ring[Req, Res]) Instance(key string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.instance(key)
+}
+
+func (r *Ring[Req, Res]) instance(key string) (string, error) {
+	if len(r.points) == 0 {
+		return "", ErrNoEndpoints
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].instance, nil
+}
+
+// Endpoint returns an Endpoint that sends every request to the instance
+// owning its key.
+func (r *Ring[Req, Res]) Endpoint() gkit.Endpoint[Req, Res] {
+	return func(ctx context.Context, request Req) (Res, error) {
+		r.mu.RLock()
+		instance, err := r.instance(r.key(ctx, request))
+		e := r.endpoints[instance]
+		r.mu.RUnlock()
+
+		if err != nil {
+			var res Res
+			return res, err
+		}
+		return e(ctx, request)
+	}
+}
+
+// hash returns the FNV-1a hash of s, passed through the finalizer of
+// MurmurHash3, since FNV alone spreads short and similar strings such as
+// "10.0.0.1:80#1" poorly across the ring.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s)) //nolint:errcheck
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
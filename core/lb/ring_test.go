@@ -0,0 +1,74 @@
+//go:build unit
+
+package lb_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"github.com/bobobox-id/gkit/core/lb"
+)
+
+func instances(names ...string) map[string]gkit.Endpoint[string, string] {
+	endpoints := make(map[string]gkit.Endpoint[string, string], len(names))
+	for _, name := range names {
+		name := name
+		endpoints[name] = func(context.Context, string) (string, error) { return name, nil }
+	}
+	return endpoints
+}
+
+func TestRingSticky(t *testing.T) {
+	r := lb.NewRing[string, string](func(_ context.Context, session string) string { return session }, 0)
+	r.Update(instances("a", "b", "c"))
+	e := r.Endpoint()
+
+	for i := 0; i < 100; i++ {
+		session := "session-" + strconv.Itoa(i)
+		first, err := e(context.Background(), session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 3; j++ {
+			if have, _ := e(context.Background(), session); first != have {
+				t.Fatalf("%s: routed to %s, then %s", session, first, have)
+			}
+		}
+	}
+}
+
+func TestRingMinimalRemapping(t *testing.T) {
+	r := lb.NewRing[string, string](func(_ context.Context, key string) string { return key }, 50)
+	r.Update(instances("a", "b", "c", "d"))
+
+	const n = 10000
+	before := make([]string, n)
+	counts := map[string]int{}
+	for i := range before {
+		before[i], _ = r.Instance(strconv.Itoa(i))
+		counts[before[i]]++
+	}
+	for name, count := range counts {
+		if count < n/8 {
+			t.Errorf("%s: owns only %d of %d keys", name, count, n)
+		}
+	}
+
+	r.Update(instances("a", "b", "c"))
+	for i, owner := range before {
+		have, _ := r.Instance(strconv.Itoa(i))
+		if owner != "d" && owner != have {
+			t.Fatalf("key %d moved from %s to %s though %s is still there", i, owner, have, owner)
+		}
+	}
+}
+
+func TestRingNoEndpoints(t *testing.T) {
+	r := lb.NewRing[string, string](func(_ context.Context, key string) string { return key }, 0)
+	if _, err := r.Endpoint()(context.Background(), "k"); !errors.Is(err, lb.ErrNoEndpoints) {
+		t.Errorf("want %v, have %v", lb.ErrNoEndpoints, err)
+	}
+}
@@ -0,0 +1,33 @@
+package lb
+
+import (
+	"sync/atomic"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// RoundRobin is a Balancer cycling through the endpoints of an Endpointer,
+// such as a Subscriber, in order.
+type RoundRobin[Req, Res any] struct {
+	s Endpointer[Req, Res]
+	c atomic.Uint64
+}
+
+// NewRoundRobin returns a RoundRobin balancer over the endpoints of s.
+func NewRoundRobin[Req, Res any](s Endpointer[Req, Res]) *RoundRobin[Req, Res] {
+	return &RoundRobin[Req, Res]{s: s}
+}
+
+// Endpoint implements Balancer.
+func (rr *RoundRobin[Req, Res]) Endpoint() (gkit.Endpoint[Req, Res], error) {
+	endpoints, err := rr.s.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	i := rr.c.Add(1) - 1
+	return endpoints[i%uint64(len(endpoints))], nil
+}
@@ -0,0 +1,79 @@
+package lb
+
+import (
+	"sync"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// WeightedEndpoint is an endpoint and its share of the traffic relative to
+// the other endpoints of a Weighted balancer.
+type WeightedEndpoint[Req, Res any] struct {
+	Endpoint gkit.Endpoint[Req, Res]
+	Weight   int
+}
+
+type weightedEntry[Req, Res any] struct {
+	endpoint gkit.Endpoint[Req, Res]
+	weight   int
+	current  int
+}
+
+// Weighted is a Balancer routing to each endpoint in proportion to its
+// weight, for backends of heterogeneous capacity. It uses smooth weighted
+// round-robin, so picks of the same endpoint are spread out rather than sent
+// in bursts: with weights 5, 1 and 1, the sequence is a a b a c a a. It is
+// safe for concurrent use.
+type Weighted[Req, Res any] struct {
+	mu      sync.Mutex
+	entries []weightedEntry[Req, Res]
+	total   int
+}
+
+// NewWeighted returns a Weighted balancer over endpoints.
+func NewWeighted[Req, Res any](endpoints ...WeightedEndpoint[Req, Res]) *Weighted[Req, Res] {
+	w := &Weighted[Req, Res]{}
+	w.Update(endpoints...)
+	return w
+}
+
+// Update replaces the endpoints and weights of the balancer, e.g. when they
+// change in service discovery, and restarts the round-robin sequence.
+// Endpoints with a weight less than or equal to zero receive no traffic.
+func (w *Weighted[Req, Res]) Update(endpoints ...WeightedEndpoint[Req, Res]) {
+	entries := make([]weightedEntry[Req, Res], 0, len(endpoints))
+	total := 0
+	for _, e := range endpoints {
+		if e.Weight <= 0 {
+			continue
+		}
+		entries = append(entries, weightedEntry[Req, Res]{endpoint: e.Endpoint, weight: e.Weight})
+		total += e.Weight
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries, w.total = entries, total
+}
+
+// Endpoint implements Balancer.
+func (w *Weighted[Req, Res]) Endpoint() (gkit.Endpoint[Req, Res], error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.entries) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	best := 0
+	for i := range w.entries {
+		w.entries[i].current += w.entries[i].weight
+		if w.entries[i].current > w.entries[best].current {
+			best = i
+		}
+	}
+	w.entries[best].current -= w.total
+
+	return w.entries[best].endpoint, nil
+}
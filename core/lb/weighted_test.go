@@ -0,0 +1,78 @@
+//go:build unit
+
+package lb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"github.com/bobobox-id/gkit/core/lb"
+)
+
+func named(name string) gkit.Endpoint[struct{}, string] {
+	return func(context.Context, struct{}) (string, error) { return name, nil }
+}
+
+func pick(t *testing.T, b lb.Balancer[struct{}, string], n int) []string {
+	t.Helper()
+
+	e := lb.Balanced(b)
+	picks := make([]string, n)
+	for i := range picks {
+		name, err := e(context.Background(), struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		picks[i] = name
+	}
+	return picks
+}
+
+func TestWeightedDistribution(t *testing.T) {
+	b := lb.NewWeighted(
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("a"), Weight: 5},
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("b"), Weight: 3},
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("c"), Weight: 2},
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("d"), Weight: 0},
+	)
+
+	counts := map[string]int{}
+	for _, name := range pick(t, b, 1000) {
+		counts[name]++
+	}
+	for name, want := range map[string]int{"a": 500, "b": 300, "c": 200, "d": 0} {
+		if have := counts[name]; want != have {
+			t.Errorf("%s: want %d picks, have %d", name, want, have)
+		}
+	}
+}
+
+func TestWeightedSmooth(t *testing.T) {
+	b := lb.NewWeighted(
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("a"), Weight: 5},
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("b"), Weight: 1},
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("c"), Weight: 1},
+	)
+
+	if want, have := "aabacaa", strings.Join(pick(t, b, 7), ""); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestWeightedUpdate(t *testing.T) {
+	b := lb.NewWeighted[struct{}, string]()
+	if _, err := b.Endpoint(); !errors.Is(err, lb.ErrNoEndpoints) {
+		t.Errorf("want %v, have %v", lb.ErrNoEndpoints, err)
+	}
+
+	b.Update(
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("a"), Weight: 1},
+		lb.WeightedEndpoint[struct{}, string]{Endpoint: named("b"), Weight: 1},
+	)
+	if want, have := "abab", strings.Join(pick(t, b, 4), ""); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,74 @@
+package gkit
+
+import (
+	"context"
+	"sync"
+)
+
+// Locker acquires exclusive locks by key, e.g. backed by a Redis redlock.
+// Acquire either waits for the lock, honoring ctx, or fails right away,
+// depending on the implementation. The returned release func unlocks the key.
+type Locker interface {
+	Acquire(ctx context.Context, key string) (release func(), err error)
+}
+
+// Lock returns a Middleware that runs the next endpoint while holding the
+// lock for the key returned by key, so that calls on the same resource never
+// run concurrently. An error from the Locker is returned without calling the
+// next endpoint.
+func Lock[Req, Res any](key func(ctx context.Context, request Req) string, locker Locker) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			release, err := locker.Acquire(ctx, key(ctx, request))
+			if err != nil {
+				var res Res
+				return res, err
+			}
+			defer release()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// LocalLocker is an in-process Locker, useful for tests and single instance
+// deployments. Acquire waits for the lock until ctx is done.
+type LocalLocker struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+// NewLocalLocker returns a ready to use LocalLocker.
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{locks: make(map[string]chan struct{})}
+}
+
+// Acquire implements Locker.
+func (l *LocalLocker) Acquire(ctx context.Context, key string) (func(), error) {
+	for {
+		l.mu.Lock()
+		held, ok := l.locks[key]
+		if !ok {
+			done := make(chan struct{})
+			l.locks[key] = done
+			l.mu.Unlock()
+
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					l.mu.Lock()
+					delete(l.locks, key)
+					l.mu.Unlock()
+					close(done)
+				})
+			}, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-held:
+		}
+	}
+}
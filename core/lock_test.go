@@ -0,0 +1,64 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestLockSerializesPerKey(t *testing.T) {
+	var (
+		running, maxRunning int32
+		wg                  sync.WaitGroup
+	)
+
+	e := gkit.Lock[string, struct{}](
+		func(_ context.Context, key string) string { return key },
+		gkit.NewLocalLocker(),
+	)(func(context.Context, string) (struct{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return struct{}{}, nil
+	})
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e(context.Background(), "order-1")
+		}()
+	}
+	wg.Wait()
+
+	if want, have := int32(1), maxRunning; want != have {
+		t.Errorf("max concurrent calls: want %d, have %d", want, have)
+	}
+}
+
+func TestLocalLockerContext(t *testing.T) {
+	locker := gkit.NewLocalLocker()
+	release, err := locker.Acquire(context.Background(), "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Acquire(ctx, "k"); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,58 @@
+package gkit
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the logger stored in ctx by WithLogger or
+// InjectLogger. If there is none, a logger discarding every record is
+// returned, so it's always safe to use.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return nopLogger
+}
+
+var nopLogger = slog.New(nopHandler{})
+
+// nopHandler is a slog.Handler that discards every record.
+type nopHandler struct{}
+
+func (nopHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (nopHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h nopHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h nopHandler) WithGroup(string) slog.Handler           { return h }
+
+// InjectLogger returns a Middleware that stores in the context a child of
+// logger pre-populated with the request ID, endpoint name and tenant found in
+// the context, so that the endpoint logs with consistent fields through
+// LoggerFromContext. Empty fields are omitted. The fields stored by
+// ExtractFields, placed before InjectLogger, are added as well.
+func InjectLogger[Req, Res any](logger *slog.Logger) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			var attrs []any
+			for _, f := range []struct{ key, value string }{
+				{"request_id", RequestIDFromContext(ctx)},
+				{"endpoint", EndpointNameFromContext(ctx)},
+				{"tenant", TenantFromContext(ctx)},
+			} {
+				if f.value != "" {
+					attrs = append(attrs, slog.String(f.key, f.value))
+				}
+			}
+			attrs = append(attrs, FieldsFromContext(ctx)...)
+
+			return next(WithLogger(ctx, logger.With(attrs...)), request)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestInjectLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	e := gkit.Chain(
+		gkit.Named[struct{}, struct{}]("GetUser"),
+		gkit.InjectLogger[struct{}, struct{}](logger),
+	)(func(ctx context.Context, _ struct{}) (struct{}, error) {
+		gkit.LoggerFromContext(ctx).Info("looking up user")
+		return struct{}{}, nil
+	})
+
+	e(gkit.WithRequestID(context.Background(), "a1b2"), struct{}{})
+
+	for _, want := range []string{"request_id=a1b2", "endpoint=GetUser", "looking up user"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in %q", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "tenant=") {
+		t.Errorf("want empty tenant omitted, have %q", buf.String())
+	}
+}
+
+func TestLoggerFromContextFallback(t *testing.T) {
+	// Must not panic nor write anywhere.
+	gkit.LoggerFromContext(context.Background()).Error("discarded")
+}
@@ -0,0 +1,47 @@
+//go:build unit
+
+package middlewaretest_test
+
+import (
+	"context"
+	"fmt"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"github.com/bobobox-id/gkit/core/middlewaretest"
+)
+
+func Example() {
+	rec := middlewaretest.NewRecorder()
+
+	limited := true
+	limiter := func(next gkit.Endpoint[string, string]) gkit.Endpoint[string, string] {
+		return func(ctx context.Context, request string) (string, error) {
+			if limited {
+				return "", gkit.ErrOverloaded
+			}
+			return next(ctx, request)
+		}
+	}
+
+	e := gkit.Chain(
+		middlewaretest.Wrap[string, string](rec, "logging", nil),
+		middlewaretest.Wrap(rec, "limiter", limiter),
+		middlewaretest.Wrap[string, string](rec, "auth", nil),
+	)(middlewaretest.Endpoint(rec, "endpoint", func(_ context.Context, request string) (string, error) {
+		return "hello " + request, nil
+	}))
+
+	_, err := e(context.Background(), "world")
+	fmt.Println(rec.Calls(), err)
+	fmt.Println("endpoint reached:", rec.Reached("endpoint"))
+
+	rec.Reset()
+	limited = false
+	res, _ := e(context.Background(), "world")
+	fmt.Println(rec.Calls(), res)
+
+	// Output:
+	// [logging limiter] overloaded
+	// endpoint reached: false
+	// [logging limiter auth endpoint] hello world
+}
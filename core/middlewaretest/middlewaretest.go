@@ -0,0 +1,98 @@
+// Package middlewaretest provides helpers to check the behavior of a stack of
+// gkit middlewares, such as the order they run in and which of them
+// short-circuit the call.
+package middlewaretest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// Recorder records the names of the middlewares and endpoints wrapped with
+// Wrap and Endpoint in the order they're reached. It's safe for concurrent
+// use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, name)
+}
+
+// Calls returns the recorded names, in the order they were reached.
+func (r *Recorder) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.calls)
+}
+
+// Reached reports whether name was recorded at least once.
+func (r *Recorder) Reached(name string) bool {
+	return slices.Contains(r.Calls(), name)
+}
+
+// Reset forgets every recorded name, so the Recorder can be reused across
+// calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+// AssertOrder fails t unless the recorded names are exactly names, in order.
+func (r *Recorder) AssertOrder(t testing.TB, names ...string) {
+	t.Helper()
+
+	if have := r.Calls(); !slices.Equal(names, have) {
+		t.Errorf("middleware order: want %v, have %v", names, have)
+	}
+}
+
+// AssertNotReached fails t if any of names was recorded, e.g. to check that an
+// expensive endpoint is never called once a rate limiter rejects the request.
+func (r *Recorder) AssertNotReached(t testing.TB, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		if r.Reached(name) {
+			t.Errorf("middleware %s: want not reached, have reached", name)
+		}
+	}
+}
+
+// Wrap returns mw recording name on the Recorder whenever the call reaches
+// it. A nil mw records name and calls the next endpoint, which is useful to
+// mark a position in the stack.
+func Wrap[Req, Res any](r *Recorder, name string, mw gkit.Middleware[Req, Res]) gkit.Middleware[Req, Res] {
+	if mw == nil {
+		mw = func(next gkit.Endpoint[Req, Res]) gkit.Endpoint[Req, Res] { return next }
+	}
+
+	return func(next gkit.Endpoint[Req, Res]) gkit.Endpoint[Req, Res] {
+		e := mw(next)
+		return func(ctx context.Context, request Req) (Res, error) {
+			r.record(name)
+			return e(ctx, request)
+		}
+	}
+}
+
+// Endpoint returns e recording name on the Recorder whenever it's called.
+func Endpoint[Req, Res any](r *Recorder, name string, e gkit.Endpoint[Req, Res]) gkit.Endpoint[Req, Res] {
+	return Wrap[Req, Res](r, name, nil)(e)
+}
@@ -0,0 +1,21 @@
+package gkit
+
+import (
+	"context"
+)
+
+// Normalize returns a Middleware that passes every request through fn before
+// calling the next endpoint, e.g. to trim strings, lowercase emails or fill
+// in defaults. Keeping that out of the business Endpoint means every server
+// and client wrapping it sees the same canonical request.
+//
+// Normalize runs where it's placed in a Chain: declare it before a validating
+// middleware to validate the normalized request, or after it to validate what
+// the caller sent.
+func Normalize[Req, Res any](fn func(Req) Req) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			return next(ctx, fn(request))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type signup struct {
+	Email string
+	Plan  string
+}
+
+func TestNormalize(t *testing.T) {
+	normalize := gkit.Normalize[signup, signup](func(s signup) signup {
+		s.Email = strings.ToLower(strings.TrimSpace(s.Email))
+		if s.Plan == "" {
+			s.Plan = "free"
+		}
+		return s
+	})
+
+	e := normalize(func(_ context.Context, s signup) (signup, error) { return s, nil })
+	have, err := e(context.Background(), signup{Email: "  Jane@Example.COM "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (signup{Email: "jane@example.com", Plan: "free"}); want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	errInvalid := errors.New("invalid email")
+	validate := func(next gkit.Endpoint[signup, signup]) gkit.Endpoint[signup, signup] {
+		return func(ctx context.Context, s signup) (signup, error) {
+			if s.Email != strings.TrimSpace(s.Email) {
+				return signup{}, errInvalid
+			}
+			return next(ctx, s)
+		}
+	}
+	trim := gkit.Normalize[signup, signup](func(s signup) signup {
+		s.Email = strings.TrimSpace(s.Email)
+		return s
+	})
+	next := func(_ context.Context, s signup) (signup, error) { return s, nil }
+	req := signup{Email: " jane@example.com"}
+
+	if _, err := gkit.Chain(trim, validate)(next)(context.Background(), req); err != nil {
+		t.Errorf("normalize before validate: want no error, have %v", err)
+	}
+	if _, err := gkit.Chain(validate, trim)(next)(context.Background(), req); !errors.Is(err, errInvalid) {
+		t.Errorf("validate before normalize: want %v, have %v", errInvalid, err)
+	}
+}
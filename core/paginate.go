@@ -0,0 +1,53 @@
+package gkit
+
+import (
+	"context"
+	"time"
+)
+
+// Paginator scans a paginated endpoint page by page. When fetching a page
+// fails, the fetch is retried from the cursor of the last successful page
+// rather than restarting the scan, so no page is processed twice.
+type Paginator[Req, Res any] struct {
+	// Endpoint fetches a single page.
+	Endpoint Endpoint[Req, Res]
+
+	// WithCursor returns a copy of the request asking for the page at cursor.
+	// It is not called for the first page.
+	WithCursor func(request Req, cursor string) Req
+
+	// NextCursor returns the cursor of the page after response, or an empty
+	// string when response is the last page.
+	NextCursor func(response Res) string
+
+	// Retries is the number of times fetching a single page is retried
+	// before the scan is aborted. The count is reset after every page.
+	Retries int
+
+	// Backoff returns how long to wait before a retry. It may be nil.
+	Backoff func(attempt int) time.Duration
+}
+
+// Scan fetches every page starting at request and passes each to fn in
+// order. It stops at the first error returned by fn, which is not retried, or
+// when fetching a page keeps failing after p.Retries attempts.
+func (p Paginator[Req, Res]) Scan(ctx context.Context, request Req, fn func(ctx context.Context, response Res) error) error {
+	fetch := Retry[Req, Res](p.Retries, p.Backoff, nil)(p.Endpoint)
+
+	for {
+		response, err := fetch(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ctx, response); err != nil {
+			return err
+		}
+
+		cursor := p.NextCursor(response)
+		if cursor == "" {
+			return nil
+		}
+		request = p.WithCursor(request, cursor)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestPaginatorResumesFromCursor(t *testing.T) {
+	var (
+		failed   bool
+		requests []string
+		seen     []int
+	)
+
+	p := gkit.Paginator[string, []int]{
+		Endpoint: func(_ context.Context, cursor string) ([]int, error) {
+			requests = append(requests, cursor)
+			page, _ := strconv.Atoi(cursor)
+			if page == 2 && !failed {
+				failed = true
+				return nil, errors.New("connection reset")
+			}
+			return []int{page * 10, page*10 + 1}, nil
+		},
+		WithCursor: func(_ string, cursor string) string { return cursor },
+		NextCursor: func(response []int) string {
+			if next := response[0]/10 + 1; next <= 3 {
+				return strconv.Itoa(next)
+			}
+			return ""
+		},
+		Retries: 1,
+	}
+
+	err := p.Scan(context.Background(), "0", func(_ context.Context, response []int) error {
+		seen = append(seen, response...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := []string{"0", "1", "2", "2", "3"}, requests; !equal(want, have) {
+		t.Errorf("requests: want %v, have %v", want, have)
+	}
+	if want, have := []int{0, 1, 10, 11, 20, 21, 30, 31}, seen; !equal(want, have) {
+		t.Errorf("items: want %v, have %v", want, have)
+	}
+}
+
+func equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
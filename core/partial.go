@@ -0,0 +1,46 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Partialer is implemented by responses that may hold a partial result, e.g.
+// search results gathered until the deadline. Transports surface it, such as
+// the HTTP EncodePartial encoder setting a header.
+type Partialer interface {
+	Partial() bool
+}
+
+// PartialDeadline returns a Middleware for endpoints able to return a partial
+// result on deadline. The next endpoint gets a context whose deadline is
+// reserve earlier than the deadline of the call, so it has time to stop
+// working and return what it has, marked with Partialer, while the caller is
+// still waiting. If it returns context.DeadlineExceeded along with a partial
+// response, the response is returned without the error. Calls without a
+// deadline are passed through untouched.
+//
+// Place it inside the middleware setting the deadline, e.g. gkit.Timeout, so
+// the reserve is taken from that deadline.
+func PartialDeadline[Req, Res any](reserve time.Duration) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return next(ctx, request)
+			}
+
+			inner, cancel := context.WithDeadline(ctx, deadline.Add(-reserve))
+			defer cancel()
+
+			response, err := next(inner, request)
+			if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				if p, ok := any(response).(Partialer); ok && p.Partial() {
+					return response, nil
+				}
+			}
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,52 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type searchResult struct {
+	Hits    []string
+	partial bool
+}
+
+func (r searchResult) Partial() bool { return r.partial }
+
+func TestPartialDeadline(t *testing.T) {
+	search := func(ctx context.Context, _ string) (searchResult, error) {
+		var res searchResult
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				res.partial = true
+				return res, ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+				res.Hits = append(res.Hits, "hit")
+				if i == 2 {
+					return res, nil
+				}
+			}
+		}
+	}
+
+	// The search needs 30ms, but only gets 50ms-40ms.
+	e := gkit.Chain(gkit.Timeout[string, searchResult](50*time.Millisecond), gkit.PartialDeadline[string, searchResult](40*time.Millisecond))(search)
+	res, err := e(context.Background(), "query")
+	if err != nil {
+		t.Fatalf("want partial result without error, have %v", err)
+	}
+	if !res.Partial() || len(res.Hits) == 0 || len(res.Hits) > 2 {
+		t.Errorf("want a partial result, have %+v", res)
+	}
+
+	// Without a deadline, the search completes.
+	res, err = gkit.PartialDeadline[string, searchResult](40*time.Millisecond)(search)(context.Background(), "query")
+	if err != nil || res.Partial() || len(res.Hits) != 3 {
+		t.Errorf("want a complete result, have %+v, %v", res, err)
+	}
+}
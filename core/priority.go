@@ -0,0 +1,160 @@
+package gkit
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrOverloaded is returned by load shedding middlewares when a request is
+// rejected because too many requests are running or waiting.
+var ErrOverloaded = errors.New("overloaded")
+
+// PriorityLimiter admits at most a fixed number of concurrent calls. Calls
+// over the limit wait in a bounded queue and are admitted by priority, highest
+// first and in arrival order for equal priorities. When the queue is full, a
+// new call either evicts the lowest priority waiting call or, if it has no
+// higher priority than any of them, is rejected. Evicted and rejected calls
+// fail with ErrOverloaded.
+type PriorityLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	running  int
+	maxQueue int
+	seq      uint64
+	queue    waitQueue
+}
+
+// NewPriorityLimiter returns a PriorityLimiter running at most limit calls
+// concurrently, with at most maxQueue calls waiting.
+func NewPriorityLimiter(limit, maxQueue int) *PriorityLimiter {
+	return &PriorityLimiter{limit: limit, maxQueue: maxQueue}
+}
+
+// Prioritize returns a Middleware that admits calls through l, using
+// priority to rank the calls that have to wait.
+func Prioritize[Req, Res any](l *PriorityLimiter, priority func(ctx context.Context, request Req) int) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			if err := l.acquire(ctx, priority(ctx, request)); err != nil {
+				var res Res
+				return res, err
+			}
+			defer l.release()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+func (l *PriorityLimiter) acquire(ctx context.Context, priority int) error {
+	l.mu.Lock()
+	if l.running < l.limit {
+		l.running++
+		l.mu.Unlock()
+		return nil
+	}
+
+	if len(l.queue) >= l.maxQueue {
+		lowest := l.queue.lowest()
+		if lowest == nil || lowest.priority >= priority {
+			l.mu.Unlock()
+			return ErrOverloaded
+		}
+		heap.Remove(&l.queue, lowest.index)
+		lowest.evicted = true
+		close(lowest.ready)
+	}
+
+	l.seq++
+	w := &waiter{priority: priority, seq: l.seq, ready: make(chan struct{})}
+	heap.Push(&l.queue, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		l.mu.Lock()
+		select {
+		case <-w.ready:
+			// Admitted or evicted concurrently with the cancellation.
+			l.mu.Unlock()
+			if !w.evicted {
+				l.release()
+			}
+		default:
+			heap.Remove(&l.queue, w.index)
+			l.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+
+	if w.evicted {
+		return ErrOverloaded
+	}
+	return nil
+}
+
+// release hands the slot of a finished call to the highest priority waiter,
+// if any.
+func (l *PriorityLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.queue) == 0 {
+		l.running--
+		return
+	}
+	w := heap.Pop(&l.queue).(*waiter)
+	close(w.ready)
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	evicted  bool
+	ready    chan struct{}
+}
+
+// waitQueue is a heap of waiters, highest priority and oldest first.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return w
+}
+
+// lowest returns the waiter that would be admitted last.
+func (q waitQueue) lowest() *waiter {
+	var lowest *waiter
+	for _, w := range q {
+		if lowest == nil || w.priority < lowest.priority || w.priority == lowest.priority && w.seq > lowest.seq {
+			lowest = w
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,63 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestPrioritize(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		order    []int
+		release  = make(chan struct{})
+		limiter  = gkit.NewPriorityLimiter(1, 2)
+		priority = func(_ context.Context, p int) int { return p }
+	)
+
+	e := gkit.Prioritize[int, struct{}](limiter, priority)(func(_ context.Context, p int) (struct{}, error) {
+		if p == 0 {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, p)
+		mu.Unlock()
+		return struct{}{}, nil
+	})
+
+	var wg sync.WaitGroup
+	errs := make(map[int]error)
+	call := func(p int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := e(context.Background(), p)
+			mu.Lock()
+			errs[p] = err
+			mu.Unlock()
+		}()
+		// Let the call reach the limiter before the next one arrives.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	call(0) // runs and blocks
+	call(1) // queued
+	call(2) // queued
+	call(3) // queue full: evicts 1
+	call(1) // queue full, not higher than any waiter: rejected
+	close(release)
+	wg.Wait()
+
+	if want, have := []int{0, 3, 2}, order; !equal(want, have) {
+		t.Errorf("order: want %v, have %v", want, have)
+	}
+	if !errors.Is(errs[1], gkit.ErrOverloaded) {
+		t.Errorf("want %v, have %v", gkit.ErrOverloaded, errs[1])
+	}
+}
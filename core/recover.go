@@ -0,0 +1,73 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// ErrPanic is wrapped by the *PanicError returned by a Recover endpoint.
+var ErrPanic = errors.New("endpoint panicked")
+
+// PanicError is returned by a Recover endpoint in place of a panic.
+type PanicError struct {
+	// Value is the value the endpoint panicked with.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+// Unwrap returns ErrPanic, or the panic value if it's an error, so both
+// match with errors.Is.
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrPanic, err}
+	}
+	return []error{ErrPanic}
+}
+
+// Recover returns a Middleware turning a panic of the next endpoint into a
+// *PanicError, so a bug in a single handler doesn't crash the whole server.
+// logger is called with the *PanicError, holding the stack trace; when nil,
+// the panic is logged with the logger of the context, see LoggerFromContext.
+// If repanicRuntime is true, runtime errors such as a nil pointer dereference
+// are logged and then re-panicked rather than converted, for services that
+// prefer crashing on programming errors.
+func Recover[Req, Res any](logger func(ctx context.Context, p any), repanicRuntime bool) Middleware[Req, Res] {
+	if logger == nil {
+		logger = func(ctx context.Context, p any) {
+			pe := p.(*PanicError)
+			LoggerFromContext(ctx).ErrorContext(ctx, "endpoint panicked", "panic", pe.Value, "stack", string(pe.Stack))
+		}
+	}
+
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (response Res, err error) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				pe := &PanicError{Value: v, Stack: debug.Stack()}
+				logger(ctx, pe)
+				if _, ok := v.(runtime.Error); ok && repanicRuntime {
+					panic(v)
+				}
+
+				var zero Res
+				response, err = zero, pe
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
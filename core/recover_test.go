@@ -0,0 +1,51 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestRecover(t *testing.T) {
+	var logged []any
+	logger := func(_ context.Context, p any) { logged = append(logged, p) }
+
+	e := gkit.Recover[string, int](logger, false)(func(context.Context, string) (int, error) {
+		panic("boom")
+	})
+	res, err := e(context.Background(), "request")
+	if !errors.Is(err, gkit.ErrPanic) {
+		t.Fatalf("want %v, have %v", gkit.ErrPanic, err)
+	}
+	var pe *gkit.PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("want a PanicError with value and stack, have %#v", err)
+	}
+	if res != 0 || len(logged) != 1 {
+		t.Errorf("want a zero response and a logged panic, have %d, %v", res, logged)
+	}
+}
+
+func TestRecoverRuntimeError(t *testing.T) {
+	nilMap := func(context.Context, string) (int, error) {
+		var m map[string]int
+		m["x"] = 1
+		return 0, nil
+	}
+
+	if _, err := gkit.Recover[string, int](func(context.Context, any) {}, false)(nilMap)(context.Background(), ""); !errors.Is(err, gkit.ErrPanic) {
+		t.Errorf("converted: want %v, have %v", gkit.ErrPanic, err)
+	}
+
+	defer func() {
+		if _, ok := recover().(runtime.Error); !ok {
+			t.Error("want the runtime error re-panicked")
+		}
+	}()
+	gkit.Recover[string, int](func(context.Context, any) {}, true)(nilMap)(context.Background(), "")
+}
@@ -0,0 +1,79 @@
+package gkit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// MethodEndpoint returns the method called name of svc as an Endpoint. The
+// method must have the signature func(context.Context, Req) (Res, error);
+// otherwise a descriptive error is returned. Reflection is only used here, so
+// calling the returned Endpoint costs the same as calling the method.
+func MethodEndpoint[Req, Res any](svc any, name string) (Endpoint[Req, Res], error) {
+	m := reflect.ValueOf(svc).MethodByName(name)
+	if !m.IsValid() {
+		return nil, fmt.Errorf("gkit: %T has no exported method %s", svc, name)
+	}
+
+	fn, ok := m.Interface().(func(context.Context, Req) (Res, error))
+	if !ok {
+		want := reflect.TypeOf((*func(context.Context, Req) (Res, error))(nil)).Elem()
+		return nil, fmt.Errorf("gkit: method %T.%s is %s, want %s", svc, name, m.Type(), want)
+	}
+
+	return fn, nil
+}
+
+// Endpoints returns every exported method of svc as an Endpoint, keyed by
+// method name. Every exported method must have the signature
+// func(context.Context, Req) (Res, error), for any Req and Res; otherwise a
+// descriptive error is returned, so a helper method exported by mistake is
+// caught when the service is wired rather than silently left out. As the
+// types are only known at run time, the endpoints take and return any and,
+// unless Req and Res are any, are invoked through reflection; a request of
+// the wrong type results in an error. Methods are resolved once, here. Prefer
+// MethodEndpoint when the types are known.
+func Endpoints(svc any) (map[string]Endpoint[any, any], error) {
+	v := reflect.ValueOf(svc)
+	endpoints := make(map[string]Endpoint[any, any])
+
+	for i := 0; i < v.NumMethod(); i++ {
+		m, name := v.Method(i), v.Type().Method(i).Name
+		if !isEndpointFunc(m.Type()) {
+			return nil, fmt.Errorf("gkit: method %T.%s is %s, want func(context.Context, Req) (Res, error)", svc, name, m.Type())
+		}
+
+		if fn, ok := m.Interface().(func(context.Context, any) (any, error)); ok {
+			endpoints[name] = fn
+			continue
+		}
+
+		reqType, zero := m.Type().In(1), reflect.Zero(m.Type().In(1))
+		endpoints[name] = func(ctx context.Context, request any) (any, error) {
+			req := reflect.ValueOf(request)
+			if !req.IsValid() {
+				req = zero
+			}
+			if !req.Type().AssignableTo(reqType) {
+				return nil, fmt.Errorf("gkit: %s expects request of type %s, got %s", name, reqType, req.Type())
+			}
+
+			out := m.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem(), req})
+			err, _ := out[1].Interface().(error)
+			return out[0].Interface(), err
+		}
+	}
+
+	return endpoints, nil
+}
+
+func isEndpointFunc(t reflect.Type) bool {
+	return t.NumIn() == 2 && t.In(0) == contextType &&
+		t.NumOut() == 2 && t.Out(1) == errorType
+}
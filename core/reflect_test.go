@@ -0,0 +1,72 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+type greeter struct{}
+
+func (greeter) Greet(_ context.Context, name string) (string, error) { return "hello " + name, nil }
+func (greeter) Shout(_ context.Context, name string) (string, error) {
+	return strings.ToUpper(name), nil
+}
+func (greeter) Helper(name string) string { return name }
+
+func TestMethodEndpoint(t *testing.T) {
+	greet, err := gkit.MethodEndpoint[string, string](greeter{}, "Greet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := greet(context.Background(), "gopher"); have != "hello gopher" {
+		t.Errorf("want %q, have %q", "hello gopher", have)
+	}
+
+	if _, err := gkit.MethodEndpoint[string, string](greeter{}, "Helper"); err == nil {
+		t.Error("want error for non-conforming method, have none")
+	}
+	if _, err := gkit.MethodEndpoint[string, string](greeter{}, "Missing"); err == nil {
+		t.Error("want error for missing method, have none")
+	}
+}
+
+type shouter struct{}
+
+func (shouter) Shout(_ context.Context, name string) (string, error) {
+	return strings.ToUpper(name), nil
+}
+func (shouter) Echo(_ context.Context, v any) (any, error) { return v, nil }
+
+func TestEndpoints(t *testing.T) {
+	endpoints, err := gkit.Endpoints(shouter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, len(endpoints); want != have {
+		t.Fatalf("want %d endpoints, have %d", want, have)
+	}
+
+	res, err := endpoints["Shout"](context.Background(), "gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "GOPHER", res; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if _, err := endpoints["Shout"](context.Background(), 42); err == nil {
+		t.Error("want error for request of the wrong type, have none")
+	}
+	if res, _ := endpoints["Echo"](context.Background(), 42); res != 42 {
+		t.Errorf("want %v, have %v", 42, res)
+	}
+
+	if _, err := gkit.Endpoints(greeter{}); err == nil || !strings.Contains(err.Error(), "Helper") {
+		t.Errorf("want error for the non-conforming method Helper, have %v", err)
+	}
+}
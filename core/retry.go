@@ -0,0 +1,42 @@
+package gkit
+
+import (
+	"context"
+	"time"
+)
+
+// Retry returns a Middleware that calls next up to max additional times while
+// it keeps failing with an error for which retryable returns true. A nil
+// retryable retries every error. Between attempts it waits for backoff(n),
+// where n starts at 1, unless the context is done first, in which case the
+// context error is returned.
+//
+// Every attempt is invoked with the caller's context. Values the caller put in
+// the context, such as a trace or request ID, are therefore seen by every
+// attempt, while values derived inside an attempt, e.g. by a Client's
+// ClientBefore funcs, are never visible to the next one.
+func Retry[Req, Res any](max int, backoff func(attempt int) time.Duration, retryable func(error) bool) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			for attempt := 0; ; attempt++ {
+				response, err := next(ctx, request)
+				if err == nil || attempt >= max || (retryable != nil && !retryable(err)) {
+					return response, err
+				}
+
+				var wait time.Duration
+				if backoff != nil {
+					wait = backoff(attempt + 1)
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return response, ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestRetry(t *testing.T) {
+	var (
+		errTransient = errors.New("transient")
+		errFatal     = errors.New("fatal")
+	)
+
+	for name, tc := range map[string]struct {
+		failures     []error
+		wantCalls    int
+		wantErr      error
+		wantBackoffs string
+	}{
+		"success":       {nil, 1, nil, "[]"},
+		"eventual":      {[]error{errTransient, errTransient}, 3, nil, "[1 2]"},
+		"exhausted":     {[]error{errTransient, errTransient, errTransient, errTransient}, 4, errTransient, "[1 2 3]"},
+		"not retryable": {[]error{errTransient, errFatal, errTransient}, 2, errFatal, "[1]"},
+		"fatal first":   {[]error{errFatal}, 1, errFatal, "[]"},
+	} {
+		var (
+			calls    int
+			backoffs []int
+		)
+		next := func(context.Context, struct{}) (int, error) {
+			calls++
+			if calls <= len(tc.failures) {
+				return 0, tc.failures[calls-1]
+			}
+			return calls, nil
+		}
+		backoff := func(attempt int) time.Duration {
+			backoffs = append(backoffs, attempt)
+			return 0
+		}
+		retryable := func(err error) bool { return !errors.Is(err, errFatal) }
+
+		res, err := gkit.Retry[struct{}, int](3, backoff, retryable)(next)(context.Background(), struct{}{})
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: want %v, have %v", name, tc.wantErr, err)
+		}
+		if want, have := tc.wantCalls, calls; want != have {
+			t.Errorf("%s: calls: want %d, have %d", name, want, have)
+		}
+		if err == nil && res != calls {
+			t.Errorf("%s: want the response of the last attempt %d, have %d", name, calls, res)
+		}
+		if have := fmt.Sprint(backoffs); tc.wantBackoffs != have {
+			t.Errorf("%s: backoff attempts: want %s, have %s", name, tc.wantBackoffs, have)
+		}
+	}
+}
+
+func TestRetryNilRetryable(t *testing.T) {
+	var calls int
+	next := func(context.Context, struct{}) (struct{}, error) {
+		calls++
+		return struct{}{}, errors.New("boom")
+	}
+
+	if _, err := gkit.Retry[struct{}, struct{}](2, nil, nil)(next)(context.Background(), struct{}{}); err == nil {
+		t.Error("want error, have none")
+	}
+	if want, have := 3, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	var calls int
+	next := func(context.Context, struct{}) (struct{}, error) {
+		calls++
+		return struct{}{}, errors.New("boom")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	backoff := func(int) time.Duration {
+		cancel()
+		return time.Hour
+	}
+
+	_, err := gkit.Retry[struct{}, struct{}](5, backoff, nil)(next)(ctx, struct{}{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want %v, have %v", context.Canceled, err)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
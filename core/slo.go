@@ -0,0 +1,153 @@
+package gkit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sloBuckets = 10
+
+type sloBucket struct {
+	index  int64
+	total  int
+	failed int
+}
+
+// SLO tracks the success rate of an endpoint over a rolling time window
+// against an objective, e.g. 99.9% of calls succeeding, and reports how much
+// of the error budget, the failures the objective allows, has been consumed.
+// Use one SLO per endpoint.
+type SLO struct {
+	objective float64
+	window    time.Duration
+	minCalls  int
+	failure   func(error) bool
+	exhausted func(ctx context.Context, consumed float64) error
+
+	mu      sync.Mutex
+	buckets [sloBuckets]sloBucket
+}
+
+// NewSLO returns an SLO for the given objective, between 0 and 1, over a
+// one-minute window. By default every error counts as a failure and an
+// exhausted budget is only observed, not acted upon.
+func NewSLO(objective float64, options ...Option[*SLO]) *SLO {
+	s := &SLO{
+		objective: objective,
+		window:    time.Minute,
+		minCalls:  10,
+		failure:   func(err error) bool { return err != nil },
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// SLOWindow sets the rolling window the success rate is computed on. It's
+// split in 10 buckets, so windows shorter than 10ns are raised to 10ns.
+func SLOWindow(d time.Duration) Option[*SLO] {
+	if d < sloBuckets {
+		d = sloBuckets
+	}
+	return func(s *SLO) { s.window = d }
+}
+
+// SLOMinCalls sets the number of calls in the window below which no budget is
+// reported as consumed, so a single early failure doesn't exhaust it.
+// Defaults to 10.
+func SLOMinCalls(n int) Option[*SLO] {
+	return func(s *SLO) { s.minCalls = n }
+}
+
+// SLOFailure sets which errors count against the budget, e.g. to leave out
+// client errors such as validation failures.
+func SLOFailure(failure func(error) bool) Option[*SLO] {
+	return func(s *SLO) { s.failure = failure }
+}
+
+// SLOExhausted sets the policy applied while the budget is exhausted. fn is
+// called before every call made in that state with the budget consumption,
+// and may flip a feature flag or alert; it should be cheap and idempotent. A
+// non-nil error is returned instead of calling the endpoint, which sheds the
+// load; see ShedLoad.
+func SLOExhausted(fn func(ctx context.Context, consumed float64) error) Option[*SLO] {
+	return func(s *SLO) { s.exhausted = fn }
+}
+
+// ShedLoad is an SLOExhausted policy rejecting every call with ErrOverloaded
+// while the budget is exhausted. Since rejected calls aren't recorded, calls
+// resume once the failures have left the window.
+func ShedLoad(context.Context, float64) error {
+	return ErrOverloaded
+}
+
+// BudgetConsumed returns the share of the error budget consumed in the
+// current window: 0 when no call failed, 1 when failures reach exactly what
+// the objective allows, and more beyond that.
+func (s *SLO) BudgetConsumed() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total, failed int
+	current := s.index(time.Now())
+	for _, b := range s.buckets {
+		if b.index > current-sloBuckets {
+			total += b.total
+			failed += b.failed
+		}
+	}
+	if total == 0 || total < s.minCalls {
+		return 0
+	}
+
+	allowed := 1 - s.objective
+	if allowed <= 0 {
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	}
+	return float64(failed) / float64(total) / allowed
+}
+
+func (s *SLO) index(now time.Time) int64 {
+	return now.UnixNano() / int64(s.window/sloBuckets)
+}
+
+func (s *SLO) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(time.Now())
+	b := &s.buckets[i%sloBuckets]
+	if b.index != i {
+		*b = sloBucket{index: i}
+	}
+	b.total++
+	if s.failure(err) {
+		b.failed++
+	}
+}
+
+// TrackSLO returns a Middleware recording the outcome of every call in s, and
+// applying its SLOExhausted policy while the budget is exhausted.
+func TrackSLO[Req, Res any](s *SLO) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			if s.exhausted != nil {
+				if consumed := s.BudgetConsumed(); consumed >= 1 {
+					if err := s.exhausted(ctx, consumed); err != nil {
+						var res Res
+						return res, err
+					}
+				}
+			}
+
+			res, err := next(ctx, request)
+			s.record(err)
+			return res, err
+		}
+	}
+}
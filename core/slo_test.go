@@ -0,0 +1,93 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestSLOBudgetConsumed(t *testing.T) {
+	s := gkit.NewSLO(0.9)
+	fail := false
+	e := gkit.TrackSLO[struct{}, struct{}](s)(func(context.Context, struct{}) (struct{}, error) {
+		if fail {
+			return struct{}{}, errors.New("fail")
+		}
+		return struct{}{}, nil
+	})
+
+	for i := 0; i < 19; i++ {
+		e(context.Background(), struct{}{}) //nolint:errcheck
+	}
+	fail = true
+	e(context.Background(), struct{}{}) //nolint:errcheck
+
+	// 1 failure in 20 calls is half of the 10% the objective allows.
+	if want, have := 0.5, s.BudgetConsumed(); math.Abs(want-have) > 1e-9 {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSLOObserveOnlyByDefault(t *testing.T) {
+	s := gkit.NewSLO(0.99, gkit.SLOMinCalls(1))
+	var calls int
+	e := gkit.TrackSLO[struct{}, struct{}](s)(func(context.Context, struct{}) (struct{}, error) {
+		calls++
+		return struct{}{}, errors.New("fail")
+	})
+
+	for i := 0; i < 5; i++ {
+		e(context.Background(), struct{}{}) //nolint:errcheck
+	}
+	if want, have := 5, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+	if have := s.BudgetConsumed(); have < 1 {
+		t.Errorf("want exhausted budget, have %v", have)
+	}
+}
+
+func TestSLOShedLoad(t *testing.T) {
+	s := gkit.NewSLO(0.5,
+		gkit.SLOWindow(50*time.Millisecond),
+		gkit.SLOMinCalls(1),
+		gkit.SLOExhausted(gkit.ShedLoad),
+	)
+	fail := true
+	e := gkit.TrackSLO[struct{}, struct{}](s)(func(context.Context, struct{}) (struct{}, error) {
+		if fail {
+			return struct{}{}, errors.New("fail")
+		}
+		return struct{}{}, nil
+	})
+
+	e(context.Background(), struct{}{}) //nolint:errcheck
+	if _, err := e(context.Background(), struct{}{}); !errors.Is(err, gkit.ErrOverloaded) {
+		t.Errorf("exhausted: want %v, have %v", gkit.ErrOverloaded, err)
+	}
+
+	fail = false
+	time.Sleep(60 * time.Millisecond)
+	if _, err := e(context.Background(), struct{}{}); err != nil {
+		t.Errorf("after the window: want no error, have %v", err)
+	}
+}
+
+func TestSLOTinyWindow(t *testing.T) {
+	for _, d := range []time.Duration{0, time.Nanosecond, -time.Second} {
+		s := gkit.NewSLO(0.9, gkit.SLOWindow(d))
+		e := gkit.TrackSLO[struct{}, struct{}](s)(func(context.Context, struct{}) (struct{}, error) {
+			return struct{}{}, nil
+		})
+		if _, err := e(context.Background(), struct{}{}); err != nil {
+			t.Errorf("%v: want no error, have %v", d, err)
+		}
+		s.BudgetConsumed()
+	}
+}
@@ -0,0 +1,28 @@
+package gkit
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// Split returns an Endpoint routing percent of the requests to b and the rest
+// to a, e.g. for canary releases or experiments. Requests are assigned to one
+// of 100 buckets by the FNV-1a hash of the key returned by key, so the same
+// key always lands on the same endpoint, and raising percent only moves keys
+// from a to b. A percent of 0 or less routes everything to a, and 100 or more
+// everything to b.
+func Split[Req, Res any](percent int, a, b Endpoint[Req, Res], key func(Req) string) Endpoint[Req, Res] {
+	return func(ctx context.Context, request Req) (Res, error) {
+		if splitBucket(key(request)) < percent {
+			return b(ctx, request)
+		}
+		return a(ctx, request)
+	}
+}
+
+// splitBucket returns the bucket of key, between 0 and 99.
+func splitBucket(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key)) //nolint:errcheck
+	return int(h.Sum32() % 100)
+}
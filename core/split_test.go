@@ -0,0 +1,51 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestSplit(t *testing.T) {
+	constant := func(name string) gkit.Endpoint[string, string] {
+		return func(context.Context, string) (string, error) { return name, nil }
+	}
+	identity := func(key string) string { return key }
+
+	route := func(percent int) map[string]string {
+		e := gkit.Split(percent, constant("a"), constant("b"), identity)
+		routes := make(map[string]string)
+		for i := 0; i < 10000; i++ {
+			key := "user-" + strconv.Itoa(i)
+			routes[key], _ = e(context.Background(), key)
+		}
+		return routes
+	}
+
+	for _, percent := range []int{0, 10, 50, 100} {
+		var toB int
+		for _, name := range route(percent) {
+			if name == "b" {
+				toB++
+			}
+		}
+		if have := toB / 100; have < percent-2 || have > percent+2 {
+			t.Errorf("%d%%: have %d%% routed to b", percent, have)
+		}
+	}
+
+	// Keys are sticky, and raising the percentage only moves keys to b.
+	low, again, high := route(10), route(10), route(20)
+	for key, name := range low {
+		if again[key] != name {
+			t.Errorf("%s: routed to %s, then to %s", key, name, again[key])
+		}
+		if name == "b" && high[key] != "b" {
+			t.Errorf("%s: routed to b at 10%% but not at 20%%", key)
+		}
+	}
+}
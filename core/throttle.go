@@ -0,0 +1,147 @@
+package gkit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Throttler admits a bounded number of concurrent calls. Calls over the limit
+// wait in a bounded queue and are admitted in arrival order. A call is
+// rejected with ErrOverloaded when the queue is full, or when it has waited
+// longer than the maximum wait. A Throttler may be shared by several
+// endpoints to bound their combined load.
+type Throttler struct {
+	limit    int
+	maxQueue int
+	maxWait  time.Duration
+
+	mu      sync.Mutex
+	running int
+	queue   []chan struct{}
+}
+
+// NewThrottler returns a Throttler running at most 100 calls concurrently,
+// with at most 100 calls waiting for as long as their context allows.
+func NewThrottler(options ...Option[*Throttler]) *Throttler {
+	t := &Throttler{limit: 100, maxQueue: 100}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+// ThrottleConcurrency sets the number of calls running concurrently.
+func ThrottleConcurrency(n int) Option[*Throttler] {
+	return func(t *Throttler) { t.limit = n }
+}
+
+// ThrottleQueue sets the number of calls waiting for a slot. With 0, calls
+// over the concurrency limit are rejected right away.
+func ThrottleQueue(n int) Option[*Throttler] {
+	return func(t *Throttler) { t.maxQueue = n }
+}
+
+// ThrottleMaxWait sets how long a call waits for a slot before it's rejected.
+// With 0, the default, it waits until its context is done.
+func ThrottleMaxWait(d time.Duration) Option[*Throttler] {
+	return func(t *Throttler) { t.maxWait = d }
+}
+
+// Running returns the number of calls currently running, e.g. for a metrics
+// exporter.
+func (t *Throttler) Running() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.running
+}
+
+// Queued returns the number of calls currently waiting for a slot, e.g. for a
+// metrics exporter.
+func (t *Throttler) Queued() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.queue)
+}
+
+// Throttle returns a Middleware that admits calls through t. Calls whose
+// context is done while waiting fail with the context error.
+func Throttle[Req, Res any](t *Throttler) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			if err := t.acquire(ctx); err != nil {
+				var res Res
+				return res, err
+			}
+			defer t.release()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+func (t *Throttler) acquire(ctx context.Context) error {
+	t.mu.Lock()
+	if t.running < t.limit {
+		t.running++
+		t.mu.Unlock()
+		return nil
+	}
+	if len(t.queue) >= t.maxQueue {
+		t.mu.Unlock()
+		return ErrOverloaded
+	}
+	ready := make(chan struct{})
+	t.queue = append(t.queue, ready)
+	t.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if t.maxWait > 0 {
+		timer := time.NewTimer(t.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = ErrOverloaded
+	}
+
+	t.mu.Lock()
+	select {
+	case <-ready:
+		// Admitted concurrently with giving up: hand the slot on.
+		t.mu.Unlock()
+		t.release()
+	default:
+		for i, r := range t.queue {
+			if r == ready {
+				t.queue = append(t.queue[:i], t.queue[i+1:]...)
+				break
+			}
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// release hands the slot of a finished call to the oldest waiter, if any.
+func (t *Throttler) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.queue) == 0 {
+		t.running--
+		return
+	}
+	ready := t.queue[0]
+	t.queue = t.queue[1:]
+	close(ready)
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestThrottle(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		order     []int
+		release   = make(chan struct{})
+		throttler = gkit.NewThrottler(gkit.ThrottleConcurrency(1), gkit.ThrottleQueue(2))
+	)
+
+	e := gkit.Throttle[int, struct{}](throttler)(func(_ context.Context, n int) (struct{}, error) {
+		if n == 0 {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, n)
+		mu.Unlock()
+		return struct{}{}, nil
+	})
+
+	var wg sync.WaitGroup
+	errs := make(map[int]error)
+	call := func(n int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := e(context.Background(), n)
+			mu.Lock()
+			errs[n] = err
+			mu.Unlock()
+		}()
+		// Let the call reach the throttler before the next one arrives.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	call(0) // runs and blocks
+	call(1) // queued
+	call(2) // queued
+	call(3) // queue full: rejected
+	if want, have := 2, throttler.Queued(); want != have {
+		t.Errorf("queued: want %d, have %d", want, have)
+	}
+	close(release)
+	wg.Wait()
+
+	if want, have := []int{0, 1, 2}, order; !equal(want, have) {
+		t.Errorf("order: want %v, have %v", want, have)
+	}
+	if !errors.Is(errs[3], gkit.ErrOverloaded) {
+		t.Errorf("want %v, have %v", gkit.ErrOverloaded, errs[3])
+	}
+	if want, have := 0, throttler.Running(); want != have {
+		t.Errorf("running: want %d, have %d", want, have)
+	}
+}
+
+func TestThrottleMaxWait(t *testing.T) {
+	var (
+		release   = make(chan struct{})
+		throttler = gkit.NewThrottler(gkit.ThrottleConcurrency(1), gkit.ThrottleMaxWait(20*time.Millisecond))
+	)
+	e := gkit.Throttle[struct{}, struct{}](throttler)(func(context.Context, struct{}) (struct{}, error) {
+		<-release
+		return struct{}{}, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e(context.Background(), struct{}{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := e(context.Background(), struct{}{}); !errors.Is(err, gkit.ErrOverloaded) {
+		t.Errorf("want %v, have %v", gkit.ErrOverloaded, err)
+	}
+	if want, have := 0, throttler.Queued(); want != have {
+		t.Errorf("queued after timeout: want %d, have %d", want, have)
+	}
+	close(release)
+	<-done
+}
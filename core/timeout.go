@@ -0,0 +1,20 @@
+package gkit
+
+import (
+	"context"
+	"time"
+)
+
+// Timeout returns a Middleware that bounds every call to the next endpoint
+// by d, through the context. The next endpoint is expected to honor the
+// context; the HTTP Client and JetStream Publisher both do.
+func Timeout[Req, Res any](d time.Duration) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestTimeout(t *testing.T) {
+	e := gkit.Timeout[time.Duration, struct{}](20 * time.Millisecond)(func(ctx context.Context, work time.Duration) (struct{}, error) {
+		select {
+		case <-time.After(work):
+			return struct{}{}, nil
+		case <-ctx.Done():
+			return struct{}{}, ctx.Err()
+		}
+	})
+
+	if _, err := e(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("fast call: want no error, have %v", err)
+	}
+	if _, err := e(context.Background(), time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("slow call: want %v, have %v", context.DeadlineExceeded, err)
+	}
+
+	// A shorter deadline of the caller is kept.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	gkit.Timeout[struct{}, struct{}](time.Hour)(func(ctx context.Context, _ struct{}) (struct{}, error) {
+		if have, _ := ctx.Deadline(); !have.Equal(deadline) {
+			t.Errorf("want the caller deadline %v, have %v", deadline, have)
+		}
+		return struct{}{}, nil
+	})(ctx, struct{}{})
+}
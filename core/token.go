@@ -0,0 +1,89 @@
+package gkit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned by a TokenStore for a token that is unknown,
+// revoked or expired.
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenStore resolves an access token to the principal it was issued to.
+type TokenStore interface {
+	Lookup(ctx context.Context, token string) (principal string, err error)
+}
+
+type tokenEntry struct {
+	principal string
+	expires   time.Time
+}
+
+// MemoryTokenStore is an in-process TokenStore, useful for tests and small
+// deployments. Expired tokens are dropped lazily when they're looked up.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]tokenEntry
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]tokenEntry)}
+}
+
+// Add issues token to principal, replacing any previous owner. The token
+// expires after ttl, or never if ttl is zero or negative.
+func (s *MemoryTokenStore) Add(token, principal string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := tokenEntry{principal: principal}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	s.tokens[token] = e
+}
+
+// Revoke invalidates token. Revoking an unknown token is a no-op.
+func (s *MemoryTokenStore) Revoke(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, token)
+}
+
+// Lookup implements TokenStore.
+func (s *MemoryTokenStore) Lookup(_ context.Context, token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.tokens[token]
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		delete(s.tokens, token)
+		return "", ErrInvalidToken
+	}
+	return e.principal, nil
+}
+
+// Authenticate returns a Middleware resolving the token returned by token
+// with store, and storing the principal with WithPrincipal before calling the
+// next endpoint. An error from the store, such as ErrInvalidToken, is
+// returned without calling the next endpoint.
+func Authenticate[Req, Res any](store TokenStore, token func(ctx context.Context, request Req) string) Middleware[Req, Res] {
+	return func(next Endpoint[Req, Res]) Endpoint[Req, Res] {
+		return func(ctx context.Context, request Req) (Res, error) {
+			principal, err := store.Lookup(ctx, token(ctx, request))
+			if err != nil {
+				var res Res
+				return res, err
+			}
+
+			return next(WithPrincipal(ctx, principal), request)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestMemoryTokenStore(t *testing.T) {
+	store := gkit.NewMemoryTokenStore()
+	store.Add("forever", "ann", 0)
+	store.Add("short", "bob", 20*time.Millisecond)
+	store.Add("revoked", "eve", time.Hour)
+	store.Revoke("revoked")
+
+	ctx := context.Background()
+	if principal, err := store.Lookup(ctx, "forever"); err != nil || principal != "ann" {
+		t.Errorf("forever: want ann, have %q, %v", principal, err)
+	}
+	if principal, err := store.Lookup(ctx, "short"); err != nil || principal != "bob" {
+		t.Errorf("short: want bob, have %q, %v", principal, err)
+	}
+	for _, token := range []string{"revoked", "unknown"} {
+		if _, err := store.Lookup(ctx, token); err != gkit.ErrInvalidToken {
+			t.Errorf("%s: want %v, have %v", token, gkit.ErrInvalidToken, err)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := store.Lookup(ctx, "short"); err != gkit.ErrInvalidToken {
+		t.Errorf("expired: want %v, have %v", gkit.ErrInvalidToken, err)
+	}
+}
+
+func TestMemoryTokenStoreConcurrent(t *testing.T) {
+	store := gkit.NewMemoryTokenStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Add("token", "ann", time.Hour)
+			store.Lookup(context.Background(), "token")
+			store.Revoke("token")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := gkit.NewMemoryTokenStore()
+	store.Add("abc", "ann", time.Hour)
+
+	e := gkit.Authenticate[string, string](store, func(_ context.Context, token string) string { return token })(
+		func(ctx context.Context, _ string) (string, error) {
+			return gkit.PrincipalFromContext(ctx), nil
+		},
+	)
+
+	if have, err := e(context.Background(), "abc"); err != nil || have != "ann" {
+		t.Errorf("want ann, have %q, %v", have, err)
+	}
+	if _, err := e(context.Background(), "nope"); err != gkit.ErrInvalidToken {
+		t.Errorf("want %v, have %v", gkit.ErrInvalidToken, err)
+	}
+}
@@ -0,0 +1,36 @@
+package gkit
+
+import (
+	"context"
+)
+
+// Translate returns an Endpoint that serves requests of one shape by calling
+// backend with another, as a gateway or protocol-translating proxy does. The
+// request is converted with req before backend is called, and its response
+// with res afterwards. An error from either conversion, or from backend, is
+// returned as is.
+//
+// Together with the transports it wires, e.g., an HTTP JSON server in front
+// of an HTTP XML client: the server decodes JSON into Req, Translate maps it
+// onto the BackendReq the client encodes as XML, and back again.
+func Translate[Req, Res, BackendReq, BackendRes any](
+	backend Endpoint[BackendReq, BackendRes],
+	req EncodeDecodeFunc[Req, BackendReq],
+	res EncodeDecodeFunc[BackendRes, Res],
+) Endpoint[Req, Res] {
+	return func(ctx context.Context, request Req) (Res, error) {
+		var response Res
+
+		backendReq, err := req(ctx, request)
+		if err != nil {
+			return response, err
+		}
+
+		backendRes, err := backend(ctx, backendReq)
+		if err != nil {
+			return response, err
+		}
+
+		return res(ctx, backendRes)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build unit
+
+package gkit_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+func TestTranslate(t *testing.T) {
+	backend := func(_ context.Context, n int) (int, error) { return n * 2, nil }
+	toInt := func(_ context.Context, s string) (int, error) { return strconv.Atoi(s) }
+	toString := func(_ context.Context, n int) (string, error) { return strconv.Itoa(n), nil }
+
+	e := gkit.Translate[string, string](backend, toInt, toString)
+
+	have, err := e(context.Background(), "21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "42"; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	var numErr *strconv.NumError
+	if _, err := e(context.Background(), "twenty-one"); !errors.As(err, &numErr) {
+		t.Errorf("want *strconv.NumError, have %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package gkit
+
+// Transport is implemented by the client side of a transport, such as the
+// HTTP Client or the JetStream Publisher, and produces an Endpoint that
+// invokes the remote service. Because the result is an ordinary Endpoint, the
+// whole middleware stack can be reused on top of any transport.
+//
+// An implementation must satisfy the following contract, which is checked by
+// the transporttest package:
+//   - the request passed to the Endpoint reaches the remote endpoint intact,
+//     and the remote response is returned intact;
+//   - an error returned by the remote endpoint results in a non-nil error;
+//   - a canceled context results in a non-nil error.
+type Transport[Req, Res any] interface {
+	Endpoint() Endpoint[Req, Res]
+}
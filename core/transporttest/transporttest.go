@@ -0,0 +1,63 @@
+// Package transporttest provides a compliance suite for implementations of
+// gkit.Transport.
+package transporttest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// Harness connects the transport under test to a server-side endpoint. It is
+// expected to start whatever is needed to serve remote, register its cleanup
+// with t.Cleanup, and return the client side of the transport.
+type Harness[Req, Res any] func(t *testing.T, remote gkit.Endpoint[Req, Res]) gkit.Transport[Req, Res]
+
+// Run checks that the transport built by harness satisfies the contract of
+// gkit.Transport, using request and response as sample payloads. Each
+// behavior is run as a subtest.
+func Run[Req, Res any](t *testing.T, harness Harness[Req, Res], request Req, response Res) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		e := harness(t, func(_ context.Context, have Req) (Res, error) {
+			if !reflect.DeepEqual(request, have) {
+				t.Errorf("request: want %#v, have %#v", request, have)
+			}
+			return response, nil
+		}).Endpoint()
+
+		have, err := e(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(response, have) {
+			t.Errorf("response: want %#v, have %#v", response, have)
+		}
+	})
+
+	t.Run("RemoteError", func(t *testing.T) {
+		e := harness(t, func(context.Context, Req) (Res, error) {
+			var res Res
+			return res, errors.New("remote failure")
+		}).Endpoint()
+
+		if _, err := e(context.Background(), request); err == nil {
+			t.Error("want error, have none")
+		}
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		e := harness(t, func(context.Context, Req) (Res, error) {
+			return response, nil
+		}).Endpoint()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := e(ctx, request); err == nil {
+			t.Error("want error, have none")
+		}
+	})
+}
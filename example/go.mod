@@ -3,9 +3,9 @@ module github.com/bobobox-id/gkit/example
 go 1.21.6
 
 require (
-	github.com/bobobox-id/gkit/core v0.1.0
-	github.com/bobobox-id/gkit/transport/http v0.2.0
-	github.com/bobobox-id/gkit/transport/jetstream v0.2.0
+	github.com/bobobox-id/gkit/core v0.2.0
+	github.com/bobobox-id/gkit/transport/http v0.3.0
+	github.com/bobobox-id/gkit/transport/jetstream v0.3.0
 	github.com/go-chi/chi/v5 v5.0.12
 	github.com/nats-io/nats-server/v2 v2.10.10
 	github.com/nats-io/nats.go v1.32.0
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+)
+
+// BatchItem is the outcome of a single item of a batch request.
+type BatchItem[T any] struct {
+	Status int    `json:"status"`
+	Data   *T     `json:"data,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResult reports the outcome of every item of a batch request, so that
+// clients can process the successes and retry only the failures. It
+// implements StatusCoder and can be encoded with EncodeJSONResponse: the
+// status is 200 when every item succeeded and 207 Multi-Status otherwise.
+type BatchResult[T any] struct {
+	Items []BatchItem[T] `json:"items"`
+}
+
+// Succeed records a successful item.
+func (b *BatchResult[T]) Succeed(data T) {
+	b.Items = append(b.Items, BatchItem[T]{Status: http.StatusOK, Data: &data})
+}
+
+// Fail records a failed item. Its status is taken from the first StatusCoder
+// in the chain of err, and is 500 otherwise.
+func (b *BatchResult[T]) Fail(err error) {
+	b.Items = append(b.Items, BatchItem[T]{Status: errorStatusCode(err), Error: err.Error()})
+}
+
+// StatusCode implements StatusCoder.
+func (b BatchResult[T]) StatusCode() int {
+	for _, item := range b.Items {
+		if item.Status < 200 || item.Status >= 300 {
+			return http.StatusMultiStatus
+		}
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,56 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestBatchResult(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(_ context.Context, ids []string) (httptransport.BatchResult[string], error) {
+			var res httptransport.BatchResult[string]
+			for _, id := range ids {
+				if id == "" {
+					res.Fail(enhancedError{})
+					continue
+				}
+				res.Succeed(strings.ToUpper(id))
+			}
+			return res, nil
+		},
+		httptransport.DecodeJSONRequest[[]string],
+		httptransport.EncodeJSONResponse[httptransport.BatchResult[string]],
+	)
+
+	for _, test := range []struct {
+		body string
+		code int
+		want string
+	}{
+		{`["a","b"]`, http.StatusOK, `{"items":[{"status":200,"data":"A"},{"status":200,"data":"B"}]}`},
+		{`["a",""]`, http.StatusMultiStatus, `{"items":[{"status":200,"data":"A"},{"status":418,"error":"enhanced error"}]}`},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body)))
+		if want, have := test.code, rec.Code; want != have {
+			t.Errorf("%s: StatusCode: want %d, have %d", test.body, want, have)
+		}
+		if want, have := test.want, strings.TrimSpace(rec.Body.String()); want != have {
+			t.Errorf("%s: Body: want %s, have %s", test.body, want, have)
+		}
+	}
+
+	var res httptransport.BatchResult[int]
+	res.Fail(errors.New("boom"))
+	if want, have := http.StatusInternalServerError, res.Items[0].Status; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,457 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// FieldError describes why a single field could not be bound.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// BindError collects every FieldError encountered while binding a request.
+// It implements StatusCoder and json.Marshaler, so DefaultErrorEncoder replies
+// with a 400 and the list of bad fields.
+type BindError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "http: invalid request: " + strings.Join(msgs, "; ")
+}
+
+// StatusCode implements StatusCoder.
+func (e *BindError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *BindError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}{"invalid request", e.Fields})
+}
+
+// BindQuery maps query parameters to the fields of T tagged with
+// `query:"name"`. Strings, booleans, integers, floats, time.Duration and
+// time.Time (RFC 3339) are converted, as are slices of them from repeated
+// parameters. A `default:"..."` tag provides the value of an absent
+// parameter, and a `query:"name,required"` tag rejects the request if it's
+// absent. All conversion errors are returned together in a *BindError.
+func BindQuery[T any](values url.Values) (T, error) {
+	var t T
+	err := bind(&t, "query", func(name string, _ bool) []string { return values[name] })
+	return t, err
+}
+
+// BindHeaders maps headers to the fields of T tagged with `header:"X-Foo"`,
+// converting the same types as BindQuery. Slices are filled from every value
+// of the header, split on commas. The default and required tags work like
+// for BindQuery.
+func BindHeaders[T any](h http.Header) (T, error) {
+	var t T
+	err := bind(&t, "header", headerLookup(h))
+	return t, err
+}
+
+// DecodeHeaderRequest is a DecodeRequestFunc that binds the headers of the
+// request to Req with BindHeaders.
+func DecodeHeaderRequest[Req any](_ context.Context, r *http.Request) (Req, error) {
+	return BindHeaders[Req](r.Header)
+}
+
+// DecodeBindRequest returns a DecodeRequestFunc filling Req from every part
+// of the request: the JSON body, if any, then the fields tagged `path:"name"`
+// from pathValue, the query as with BindQuery and the headers as with
+// BindHeaders. As the standard library has no router, pathValue returns the
+// path parameters of the router in use, e.g. mux.Vars(r)[name]; a nil
+// pathValue skips path binding. A malformed body and all conversion errors
+// are returned together in a *BindError.
+//
+// Fields tagged path, query or header are only bound from that part of the
+// request and never from the body, so a client can't set, e.g., a field bound
+// to an X-User-Id header set by a gateway by sending it in the body instead.
+func DecodeBindRequest[Req any](pathValue func(r *http.Request, name string) string) gkit.EncodeDecodeFunc[*http.Request, Req] {
+	return func(_ context.Context, r *http.Request) (Req, error) {
+		var (
+			req  Req
+			errs []FieldError
+		)
+
+		if r.Body != nil && r.Body != http.NoBody {
+			defer r.Body.Close()
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+				errs = append(errs, FieldError{Field: "body", Message: err.Error()})
+			}
+			clearTaggedFields(&req, "path", "query", "header")
+		}
+
+		lookups := map[string]func(string, bool) []string{
+			"query":  func(name string, _ bool) []string { return r.URL.Query()[name] },
+			"header": headerLookup(r.Header),
+		}
+		if pathValue != nil {
+			lookups["path"] = func(name string, _ bool) []string {
+				if v := pathValue(r, name); v != "" {
+					return []string{v}
+				}
+				return nil
+			}
+		}
+		for _, tag := range []string{"path", "query", "header"} {
+			if lookups[tag] == nil {
+				continue
+			}
+
+			var bindErr *BindError
+			if err := bind(&req, tag, lookups[tag]); errors.As(err, &bindErr) {
+				errs = append(errs, bindErr.Fields...)
+			} else if err != nil {
+				return req, err
+			}
+		}
+
+		if len(errs) > 0 {
+			return req, &BindError{Fields: errs}
+		}
+		return req, nil
+	}
+}
+
+func headerLookup(h http.Header) func(name string, multi bool) []string {
+	return func(name string, multi bool) []string {
+		values := h.Values(name)
+		if !multi {
+			return values
+		}
+
+		var split []string
+		for _, v := range values {
+			for _, part := range strings.Split(v, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					split = append(split, part)
+				}
+			}
+		}
+		return split
+	}
+}
+
+// DecodeQueryRequest is a DecodeRequestFunc that binds the query parameters
+// of the request to Req with BindQuery.
+func DecodeQueryRequest[Req any](_ context.Context, r *http.Request) (Req, error) {
+	return BindQuery[Req](r.URL.Query())
+}
+
+// bind sets the fields of the struct pointed to by dst that carry tag, using
+// lookup to fetch their raw values. multi reports whether the field is a
+// slice.
+func bind(dst any, tag string, lookup func(name string, multi bool) []string) error {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("http: cannot bind %s values to %s, want a struct", tag, v.Type())
+	}
+
+	var errs []FieldError
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		raw := lookup(name, field.Type.Kind() == reflect.Slice)
+		if len(raw) == 0 {
+			// Keep a value already set, e.g. by the body.
+			if !v.Field(i).IsZero() {
+				continue
+			}
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				if opts == "required" {
+					errs = append(errs, FieldError{Field: name, Message: "required"})
+				}
+				continue
+			}
+			raw = []string{def}
+		}
+
+		if err := setField(v.Field(i), raw); err != nil {
+			errs = append(errs, FieldError{Field: name, Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &BindError{Fields: errs}
+	}
+	return nil
+}
+
+// clearTaggedFields zeroes the fields of the struct pointed to by dst that
+// carry one of tags.
+func clearTaggedFields(dst any, tags ...string) {
+	v := reflect.ValueOf(dst).Elem()
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		for _, tag := range tags {
+			if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+				v.Field(i).SetZero()
+				break
+			}
+		}
+	}
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+func setField(f reflect.Value, raw []string) error {
+	if f.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(f.Type(), len(raw), len(raw))
+		for i, r := range raw {
+			if err := setValue(s.Index(i), r); err != nil {
+				return err
+			}
+		}
+		f.Set(s)
+		return nil
+	}
+	return setValue(f, raw[0])
+}
+
+func setValue(f reflect.Value, raw string) error {
+	switch {
+	case f.Type() == timeType:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return fmt.Errorf("invalid time %q, want RFC 3339", raw)
+		}
+		f.Set(reflect.ValueOf(t))
+		return nil
+	case f.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
+
+// EncodeQueryParams returns an EncodeRequestFunc that appends the fields of
+// Req tagged with `<tag>:"name"` to the query of the request, the reverse of
+// BindQuery. An empty tag means "url". The same types as BindQuery are
+// formatted, with slices producing repeated parameters. Fields tagged
+// `,omitempty` are skipped when zero, and untagged struct fields are
+// flattened into the query, so their tagged fields obey the same rules.
+func EncodeQueryParams[Req any](tag string) EncodeRequestFunc[Req] {
+	if tag == "" {
+		tag = "url"
+	}
+
+	return func(_ context.Context, r *http.Request, req Req) error {
+		query := url.Values{}
+		err := encodeFields(req, tag, "query parameter", false, func(name string, values []string) {
+			query[name] = append(query[name], values...)
+		})
+		if err != nil || len(query) == 0 {
+			return err
+		}
+
+		if r.URL.RawQuery != "" {
+			r.URL.RawQuery += "&"
+		}
+		r.URL.RawQuery += query.Encode()
+		return nil
+	}
+}
+
+type headerConfig struct {
+	includeZero bool
+}
+
+// HeaderOption sets an optional parameter of EncodeRequestHeaders.
+type HeaderOption gkit.Option[*headerConfig]
+
+// HeaderIncludeZero sets whether fields with a zero value are sent as headers.
+// By default they're skipped, as if tagged `,omitempty`.
+func HeaderIncludeZero(include bool) HeaderOption {
+	return func(c *headerConfig) { c.includeZero = include }
+}
+
+// EncodeRequestHeaders wraps enc, setting a header for every field of Req
+// tagged with `header:"X-Foo"` once enc has encoded the request, so a single
+// request struct carries both body and header fields. Tag header fields
+// `json:"-"` to keep them out of a JSON body. The same types as BindQuery are
+// formatted, with slices joined into one comma-separated value. Unlike
+// Headerer, the headers are declared on the fields themselves.
+func EncodeRequestHeaders[Req any](enc EncodeRequestFunc[Req], options ...HeaderOption) EncodeRequestFunc[Req] {
+	var c headerConfig
+	for _, option := range options {
+		option(&c)
+	}
+
+	return func(ctx context.Context, r *http.Request, req Req) error {
+		if err := enc(ctx, r, req); err != nil {
+			return err
+		}
+
+		return encodeFields(req, "header", "header", !c.includeZero, func(name string, values []string) {
+			r.Header.Set(name, strings.Join(values, ", "))
+		})
+	}
+}
+
+// encodeFields formats the fields of the struct req that carry tag, calling
+// add with the values of each. Fields tagged `,omitempty` are skipped when
+// zero, as is every zero field if omitZero is set. Untagged struct fields are
+// flattened.
+func encodeFields(req any, tag, what string, omitZero bool, add func(name string, values []string)) error {
+	v := reflect.ValueOf(req)
+	if !v.IsValid() {
+		return fmt.Errorf("http: cannot encode nil as %ss, want a struct", what)
+	}
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("http: cannot encode %s as %ss, want a struct", v.Type(), what)
+	}
+	return encodeStruct(v, tag, what, omitZero, add)
+}
+
+func encodeStruct(v reflect.Value, tag, what string, omitZero bool, add func(name string, values []string)) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, f := v.Type().Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		omitempty := omitZero || opts == "omitempty"
+
+		for f.Kind() == reflect.Pointer {
+			if f.IsNil() {
+				break
+			}
+			f = f.Elem()
+		}
+		if name == "" {
+			if f.Kind() == reflect.Struct && f.Type() != timeType {
+				if err := encodeStruct(f, tag, what, omitZero, add); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if f.Kind() == reflect.Pointer || (omitempty && f.IsZero()) || (omitempty && f.Kind() == reflect.Slice && f.Len() == 0) {
+			continue
+		}
+
+		elems := []reflect.Value{f}
+		if f.Kind() == reflect.Slice {
+			elems = make([]reflect.Value, f.Len())
+			for j := range elems {
+				elems[j] = f.Index(j)
+			}
+		}
+
+		values := make([]string, len(elems))
+		for j, elem := range elems {
+			s, err := formatValue(elem)
+			if err != nil {
+				return fmt.Errorf("http: cannot encode %s %s: %w", what, name, err)
+			}
+			values[j] = s
+		}
+		add(name, values)
+	}
+	return nil
+}
+
+func formatValue(f reflect.Value) (string, error) {
+	switch f.Type() {
+	case timeType:
+		return f.Interface().(time.Time).Format(time.RFC3339), nil
+	case durationType:
+		return time.Duration(f.Int()).String(), nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(f.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'g', -1, f.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported field type %s", f.Type())
+	}
+}
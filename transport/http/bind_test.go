@@ -0,0 +1,266 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type searchQuery struct {
+	Q      string        `query:"q"`
+	Page   int           `query:"page" default:"1"`
+	Score  float64       `query:"score"`
+	Exact  bool          `query:"exact"`
+	Since  time.Time     `query:"since"`
+	Within time.Duration `query:"within"`
+	Tags   []string      `query:"tag"`
+	Ignore string
+}
+
+func TestBindQuery(t *testing.T) {
+	values, _ := url.ParseQuery("q=hotel&score=4.5&exact=true&since=2024-01-02T03:04:05Z&within=1h&tag=a&tag=b&Ignore=x")
+
+	q, err := httptransport.BindQuery[searchQuery](values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := searchQuery{
+		Q:      "hotel",
+		Page:   1,
+		Score:  4.5,
+		Exact:  true,
+		Since:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Within: time.Hour,
+		Tags:   []string{"a", "b"},
+	}
+	if q.Q != want.Q || q.Page != want.Page || q.Score != want.Score || q.Exact != want.Exact ||
+		!q.Since.Equal(want.Since) || q.Within != want.Within || len(q.Tags) != 2 || q.Tags[1] != "b" || q.Ignore != "" {
+		t.Errorf("want %+v, have %+v", want, q)
+	}
+}
+
+func TestBindQueryErrors(t *testing.T) {
+	values, _ := url.ParseQuery("page=two&exact=maybe")
+
+	_, err := httptransport.BindQuery[searchQuery](values)
+
+	var bindErr *httptransport.BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("want *BindError, have %v", err)
+	}
+	if want, have := http.StatusBadRequest, bindErr.StatusCode(); want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := 2, len(bindErr.Fields); want != have {
+		t.Fatalf("want %d field errors, have %d: %v", want, have, bindErr.Fields)
+	}
+	if want, have := "page", bindErr.Fields[0].Field; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "exact", bindErr.Fields[1].Field; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+type listFilter struct {
+	Status []string  `url:"status,omitempty"`
+	After  time.Time `url:"after,omitempty"`
+	Limit  int       `url:"limit"`
+}
+
+type listQuery struct {
+	Q      string `url:"q,omitempty"`
+	Filter listFilter
+	Cursor *string `url:"cursor,omitempty"`
+	Secret string  `url:"-"`
+}
+
+func TestEncodeQueryParams(t *testing.T) {
+	after := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cursor := "abc"
+
+	for _, tc := range []struct {
+		req  listQuery
+		want string
+	}{
+		{listQuery{}, "limit=0"},
+		{
+			listQuery{Q: "go", Filter: listFilter{Status: []string{"open", "closed"}, After: after, Limit: 10}, Cursor: &cursor, Secret: "x"},
+			"after=2024-01-02T03%3A04%3A05Z&cursor=abc&limit=10&q=go&status=open&status=closed",
+		},
+		{listQuery{Filter: listFilter{Status: []string{}}}, "limit=0"},
+	} {
+		r, _ := http.NewRequest(http.MethodGet, "http://example.com/items?page=2", nil)
+		if err := httptransport.EncodeQueryParams[listQuery]("")(context.Background(), r, tc.req); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := "page=2&"+tc.want, r.URL.RawQuery; want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+	}
+}
+
+type createOrder struct {
+	Item      string   `json:"item"`
+	RequestID string   `json:"-" header:"X-Request-Id"`
+	Tags      []string `json:"-" header:"X-Tags"`
+	Priority  int      `json:"-" header:"X-Priority"`
+}
+
+func TestEncodeQueryParamsNil(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := httptransport.EncodeQueryParams[any]("")(context.Background(), r, nil); err == nil {
+		t.Error("want an error for a nil request, have none")
+	}
+	if err := httptransport.EncodeRequestHeaders(func(context.Context, *http.Request, any) error { return nil })(context.Background(), r, nil); err == nil {
+		t.Error("want an error for a nil request, have none")
+	}
+}
+
+func TestEncodeRequestHeaders(t *testing.T) {
+	for _, tc := range []struct {
+		options  []httptransport.HeaderOption
+		priority []string
+	}{
+		{nil, nil},
+		{[]httptransport.HeaderOption{httptransport.HeaderIncludeZero(true)}, []string{"0"}},
+	} {
+		r, _ := http.NewRequest(http.MethodPost, "http://example.com", nil)
+		enc := httptransport.EncodeRequestHeaders(httptransport.EncodeJSONRequest[createOrder], tc.options...)
+		if err := enc(context.Background(), r, createOrder{Item: "book", RequestID: "abc", Tags: []string{"gift", "rush"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if want, have := "abc", r.Header.Get("X-Request-Id"); want != have {
+			t.Errorf("X-Request-Id: want %q, have %q", want, have)
+		}
+		if want, have := "gift, rush", r.Header.Get("X-Tags"); want != have {
+			t.Errorf("X-Tags: want %q, have %q", want, have)
+		}
+		if want, have := tc.priority, r.Header.Values("X-Priority"); len(want) != len(have) || (len(want) > 0 && want[0] != have[0]) {
+			t.Errorf("X-Priority: want %v, have %v", want, have)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if want, have := "{\"item\":\"book\"}\n", string(body); want != have {
+			t.Errorf("body: want %q, have %q", want, have)
+		}
+	}
+}
+
+type tracedRequest struct {
+	Trace   string   `header:"X-Trace-Id,required"`
+	Retries int      `header:"X-Retries" default:"3"`
+	Langs   []string `header:"Accept-Language"`
+}
+
+func TestBindHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Trace-Id", "abc")
+	h.Add("Accept-Language", "en, id")
+	h.Add("Accept-Language", "fr")
+
+	have, err := httptransport.BindHeaders[tracedRequest](h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.Trace != "abc" || have.Retries != 3 || len(have.Langs) != 3 || have.Langs[2] != "fr" {
+		t.Errorf("unexpected binding %+v", have)
+	}
+
+	_, err = httptransport.BindHeaders[tracedRequest](http.Header{"X-Retries": {"many"}})
+	var bindErr *httptransport.BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("want *BindError, have %v", err)
+	}
+	if want, have := 2, len(bindErr.Fields); want != have {
+		t.Errorf("want %d field errors, have %v", want, bindErr.Fields)
+	}
+	if want, have := http.StatusBadRequest, bindErr.StatusCode(); want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+}
+
+type updateUser struct {
+	ID     string `json:"-" path:"id"`
+	Name   string `json:"name"`
+	DryRun bool   `json:"-" query:"dry_run"`
+	Token  string `json:"-" header:"Authorization,required"`
+}
+
+func TestDecodeBindRequest(t *testing.T) {
+	dec := httptransport.DecodeBindRequest[updateUser](func(r *http.Request, name string) string {
+		if name == "id" {
+			return strings.TrimPrefix(r.URL.Path, "/users/")
+		}
+		return ""
+	})
+
+	r := httptest.NewRequest(http.MethodPut, "/users/42?dry_run=true", strings.NewReader(`{"name":"ann"}`))
+	r.Header.Set("Authorization", "Bearer x")
+	have, err := dec(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (updateUser{ID: "42", Name: "ann", DryRun: true, Token: "Bearer x"}); want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+
+	r = httptest.NewRequest(http.MethodPut, "/users/42?dry_run=maybe", strings.NewReader(`{"name":`))
+	_, err = dec(context.Background(), r)
+	var bindErr *httptransport.BindError
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("want *BindError, have %v", err)
+	}
+	fields := map[string]bool{}
+	for _, f := range bindErr.Fields {
+		fields[f.Field] = true
+	}
+	for _, field := range []string{"body", "dry_run", "Authorization"} {
+		if !fields[field] {
+			t.Errorf("want an error for %s, have %v", field, bindErr.Fields)
+		}
+	}
+}
+
+type createUser struct {
+	Name  string `json:"name"`
+	Role  string `json:"role" query:"role" default:"member"`
+	Email string `json:"email" query:"email,required"`
+	Owner string `json:"owner" header:"X-User-Id"`
+}
+
+func TestDecodeBindRequestBody(t *testing.T) {
+	dec := httptransport.DecodeBindRequest[createUser](nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/users?email=ann@example.com", strings.NewReader(`{"name":"ann","role":"admin","owner":"mallory"}`))
+	r.Header.Set("X-User-Id", "7")
+	have, err := dec(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (createUser{Name: "ann", Role: "member", Email: "ann@example.com", Owner: "7"}); want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"ann","email":"ann@example.com","owner":"mallory"}`))
+	have, err = dec(context.Background(), r)
+	var bindErr *httptransport.BindError
+	if !errors.As(err, &bindErr) || len(bindErr.Fields) != 1 || bindErr.Fields[0].Field != "email" {
+		t.Fatalf("want a single error for email, have %v", err)
+	}
+	if want := (createUser{Name: "ann", Role: "member"}); want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
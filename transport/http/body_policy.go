@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// BodyPolicy tells whether requests of a method must, may or must not carry a
+// body.
+type BodyPolicy int
+
+const (
+	// BodyAllowed accepts requests with or without a body.
+	BodyAllowed BodyPolicy = iota
+
+	// BodyRequired rejects requests without a body.
+	BodyRequired
+
+	// BodyForbidden rejects requests with a body.
+	BodyForbidden
+)
+
+// DefaultBodyPolicies forbids a body on GET, HEAD and DELETE requests, whose
+// body has no defined meaning, and requires one on POST, PUT and PATCH
+// requests. Other methods accept either.
+var DefaultBodyPolicies = map[string]BodyPolicy{
+	http.MethodGet:    BodyForbidden,
+	http.MethodHead:   BodyForbidden,
+	http.MethodDelete: BodyForbidden,
+	http.MethodPost:   BodyRequired,
+	http.MethodPut:    BodyRequired,
+	http.MethodPatch:  BodyRequired,
+}
+
+// BodyError is returned when a request breaks the body policy of its method.
+// It implements StatusCoder, so the DefaultErrorEncoder replies 400.
+type BodyError struct {
+	Method string
+	Policy BodyPolicy
+}
+
+// Error implements the error interface.
+func (e *BodyError) Error() string {
+	if e.Policy == BodyRequired {
+		return fmt.Sprintf("http: %s request requires a body", e.Method)
+	}
+	return fmt.Sprintf("http: %s request must not have a body", e.Method)
+}
+
+// StatusCode implements StatusCoder.
+func (e *BodyError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// ServerBodyPolicy checks, before decoding, that the request carries a body
+// or not as policies require for its method, and fails with a *BodyError
+// otherwise. Methods missing from policies accept either. A nil policies
+// means DefaultBodyPolicies. A body of unknown length, sent chunked, counts
+// as present.
+func ServerBodyPolicy[Req, Res any](policies map[string]BodyPolicy) ServerOption[Req, Res] {
+	if policies == nil {
+		policies = DefaultBodyPolicies
+	}
+
+	return func(s *Server[Req, Res]) {
+		dec := s.dec
+		s.dec = func(ctx context.Context, r *http.Request) (Req, error) {
+			policy, hasBody := policies[r.Method], r.ContentLength != 0
+			if policy == BodyRequired && !hasBody || policy == BodyForbidden && hasBody {
+				var req Req
+				return req, &BodyError{Method: r.Method, Policy: policy}
+			}
+			return dec(ctx, r)
+		}
+	}
+}
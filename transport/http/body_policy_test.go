@@ -0,0 +1,68 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestServerBodyPolicy(t *testing.T) {
+	handler := httptransport.NewServer(
+		gkit.NopEndpoint[struct{}, struct{}],
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		gkit.NopResponseEncoder[struct{}, http.ResponseWriter],
+		httptransport.ServerBodyPolicy[struct{}, struct{}](nil),
+		httptransport.ServerErrorHandler[struct{}, struct{}](gkit.ErrorHandlerFunc(func(context.Context, error) {})),
+	)
+
+	for _, tc := range []struct {
+		method string
+		body   io.Reader
+		want   int
+	}{
+		{http.MethodGet, nil, http.StatusOK},
+		{http.MethodGet, strings.NewReader("{}"), http.StatusBadRequest},
+		{http.MethodDelete, strings.NewReader("{}"), http.StatusBadRequest},
+		{http.MethodPost, strings.NewReader("{}"), http.StatusOK},
+		{http.MethodPost, nil, http.StatusBadRequest},
+		{http.MethodOptions, strings.NewReader("{}"), http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, "/", tc.body))
+		if have := w.Code; tc.want != have {
+			t.Errorf("%s, body %v: want %d, have %d", tc.method, tc.body != nil, tc.want, have)
+		}
+	}
+}
+
+func TestServerBodyPolicyCustom(t *testing.T) {
+	var have error
+	handler := httptransport.NewServer(
+		gkit.NopEndpoint[struct{}, struct{}],
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		gkit.NopResponseEncoder[struct{}, http.ResponseWriter],
+		httptransport.ServerBodyPolicy[struct{}, struct{}](map[string]httptransport.BodyPolicy{
+			http.MethodDelete: httptransport.BodyRequired,
+		}),
+		httptransport.ServerErrorHandler[struct{}, struct{}](gkit.ErrorHandlerFunc(func(_ context.Context, err error) { have = err })),
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/", nil))
+
+	var bodyErr *httptransport.BodyError
+	if !errors.As(have, &bodyErr) || bodyErr.Policy != httptransport.BodyRequired {
+		t.Fatalf("want *BodyError requiring a body, have %v", have)
+	}
+	if want := "http: DELETE request requires a body"; want != bodyErr.Error() {
+		t.Errorf("want %q, have %q", want, bodyErr.Error())
+	}
+}
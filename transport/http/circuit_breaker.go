@@ -0,0 +1,60 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CircuitBreaker models the Execute method of a circuit breaker such as
+// *gobreaker.CircuitBreaker from github.com/sony/gobreaker. Execute runs req
+// unless the breaker is open, and records whether it failed.
+type CircuitBreaker interface {
+	Execute(req func() (any, error)) (any, error)
+}
+
+// ErrCircuitOpen is returned by the endpoint of a Client, wrapped together with
+// the error of the breaker, when its CircuitBreaker rejects the call without
+// sending the request.
+var ErrCircuitOpen = errors.New("http: circuit breaker open")
+
+// breakerClient is an HTTPClient sending requests through a CircuitBreaker.
+// Transport errors and 5xx responses count as failures.
+type breakerClient struct {
+	next HTTPClient
+	cb   CircuitBreaker
+}
+
+// serverFailure fails a call to the breaker while keeping the response, which
+// is still decoded by the Client.
+type serverFailure struct {
+	resp *http.Response
+}
+
+func (e *serverFailure) Error() string {
+	return fmt.Sprintf("http: server failure %d %s", e.resp.StatusCode, http.StatusText(e.resp.StatusCode))
+}
+
+func (b breakerClient) Do(req *http.Request) (*http.Response, error) {
+	var called bool
+	v, err := b.cb.Execute(func() (any, error) {
+		called = true
+		resp, err := b.next.Do(req)
+		if err == nil && resp.StatusCode >= 500 {
+			return nil, &serverFailure{resp: resp}
+		}
+		return resp, err
+	})
+
+	var failure *serverFailure
+	switch {
+	case !called:
+		closeBody(req)
+		return nil, fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+	case errors.As(err, &failure):
+		return failure.resp, nil
+	case err != nil:
+		return nil, err
+	}
+	return v.(*http.Response), nil
+}
@@ -2,11 +2,18 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	gkit "github.com/bobobox-id/gkit/core"
 )
@@ -22,9 +29,72 @@ type Client[Req, Res any] struct {
 	req            gkit.EncodeDecodeFunc[Req, *http.Request]
 	dec            gkit.EncodeDecodeFunc[*http.Response, Res]
 	before         []RequestFunc
+	defaultHeaders []defaultHeader
+	idempotency    func(context.Context, Req) string
+	idempotencyHdr string
 	after          []ClientResponseFunc
+	afterDecode    []ClientResponseFunc
 	finalizer      []ClientFinalizerFunc
 	bufferedStream bool
+	streamLifetime time.Duration
+	streamIdle     time.Duration
+	retry          func(HTTPClient) HTTPClient
+	breaker        CircuitBreaker
+	limiter        Limiter
+	gzip           bool
+	timeout        time.Duration
+	errorDecoder   func(*http.Response) error
+	checkRedirect  func(*http.Request, []*http.Request) error
+	jar            http.CookieJar
+	compression    *CompressionNegotiator
+	lifecycle      *clientLifecycle
+}
+
+// ErrClientClosed is returned by the endpoint of a Client once Close has been
+// called.
+var ErrClientClosed = errors.New("http: client closed")
+
+// ErrMissingPathValue is returned by the endpoint of a Client created with
+// NewPathClient when no value is extracted for a placeholder of the path
+// template.
+var ErrMissingPathValue = errors.New("http: missing path value")
+
+// ErrResolveTarget is wrapped by the error returned by the endpoint of a
+// Client created with NewClientResolved when the target cannot be resolved.
+var ErrResolveTarget = errors.New("http: resolve target")
+
+// clientLifecycle tracks the in-flight calls and open buffered streams of a
+// Client, so Close can drain them.
+type clientLifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+	streams  map[*bodyWithCancel]struct{}
+}
+
+// begin registers a new call, unless the client is closed.
+func (l *clientLifecycle) begin() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return false
+	}
+	l.inflight.Add(1)
+	return true
+}
+
+func (l *clientLifecycle) track(body *bodyWithCancel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.streams[body] = struct{}{}
+	body.release = func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		delete(l.streams, body)
+	}
 }
 
 // NewClient constructs a usable Client for a single remote method.
@@ -32,18 +102,50 @@ func NewClient[Req, Res any](method string, tgt *url.URL, enc EncodeRequestFunc[
 	return NewExplicitClient[Req, Res](makeCreateRequestFunc(method, tgt, enc), dec, options...)
 }
 
+// NewPathClient is like NewClient for REST resources, e.g.
+// "/users/{id}/orders/{orderID}". The target of every request is the path
+// template appended to the path of base, with each {name} placeholder
+// replaced by the URL-escaped value extract returns for name. If a value is
+// missing or empty, the endpoint returns an error wrapping ErrMissingPathValue
+// without sending the request.
+func NewPathClient[Req, Res any](method, pathTemplate string, base *url.URL, extract func(Req) map[string]string, enc EncodeRequestFunc[Req], dec gkit.EncodeDecodeFunc[*http.Response, Res], options ...ClientOption[Req, Res]) *Client[Req, Res] {
+	return NewExplicitClient[Req, Res](makePathRequestFunc(method, pathTemplate, base, extract, enc), dec, options...)
+}
+
+// NewClientResolved is like NewClient for targets that may move, e.g. on
+// blue/green deployments or failover. resolve is called for every request to
+// get the current target, so the Client needn't be recreated. If it fails,
+// the endpoint returns an error wrapping both ErrResolveTarget and the
+// resolver error without sending the request.
+func NewClientResolved[Req, Res any](method string, resolve func(context.Context) (*url.URL, error), enc EncodeRequestFunc[Req], dec gkit.EncodeDecodeFunc[*http.Response, Res], options ...ClientOption[Req, Res]) *Client[Req, Res] {
+	return NewExplicitClient[Req, Res](makeResolvedRequestFunc(method, resolve, enc), dec, options...)
+}
+
 // NewExplicitClient is like NewClient but uses a CreateRequestFunc instead of a
 // method, target URL, and EncodeRequestFunc, which allows for more control over
 // the outgoing HTTP request.
 func NewExplicitClient[Req, Res any](req gkit.EncodeDecodeFunc[Req, *http.Request], dec gkit.EncodeDecodeFunc[*http.Response, Res], options ...ClientOption[Req, Res]) *Client[Req, Res] {
 	c := &Client[Req, Res]{
-		client: http.DefaultClient,
-		req:    req,
-		dec:    dec,
+		client:    http.DefaultClient,
+		req:       req,
+		dec:       dec,
+		lifecycle: &clientLifecycle{streams: make(map[*bodyWithCancel]struct{})},
 	}
 	for _, option := range options {
 		option(c)
 	}
+	if hc, ok := c.client.(*http.Client); ok && (c.checkRedirect != nil || c.jar != nil) {
+		// Copy the client, so a shared one such as http.DefaultClient keeps
+		// its redirect policy and jar.
+		withPolicy := *hc
+		if c.checkRedirect != nil {
+			withPolicy.CheckRedirect = c.checkRedirect
+		}
+		if c.jar != nil {
+			withPolicy.Jar = c.jar
+		}
+		c.client = &withPolicy
+	}
 	return c
 }
 
@@ -56,12 +158,49 @@ func SetClient[Req, Res any](client HTTPClient) ClientOption[Req, Res] {
 	return func(c *Client[Req, Res]) { c.client = client }
 }
 
+// ClientCheckRedirect sets the redirect policy of the client, in the form of
+// http.Client.CheckRedirect, e.g. returning http.ErrUseLastResponse to get
+// the redirect response itself instead of following it. The policy is set on
+// a copy of the underlying client, so a shared client such as
+// http.DefaultClient is left untouched. It's a no-op if the HTTPClient set
+// with SetClient isn't an *http.Client.
+func ClientCheckRedirect[Req, Res any](policy func(req *http.Request, via []*http.Request) error) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.checkRedirect = policy }
+}
+
+// ClientCookieJar sets the cookie jar of the client, so cookies set by
+// responses, e.g. a session cookie returned on login, are sent on later
+// calls. Like ClientCheckRedirect, the jar is set on a copy of the
+// underlying client, and it's a no-op if the HTTPClient set with SetClient
+// isn't an *http.Client; use SetRequestCookies to attach cookies explicitly
+// instead. Share the jar between the Clients of a session.
+func ClientCookieJar[Req, Res any](jar http.CookieJar) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.jar = jar }
+}
+
 // ClientBefore adds one or more RequestFuncs to be applied to the outgoing HTTP
 // request before it's invoked.
 func ClientBefore[Req, Res any](before ...RequestFunc) ClientOption[Req, Res] {
 	return func(c *Client[Req, Res]) { c.before = append(c.before, before...) }
 }
 
+type defaultHeader struct {
+	key, value string
+	override   bool
+}
+
+// ClientDefaultHeader sets a header on every outgoing request, e.g. a
+// consistent User-Agent or X-Client-Version, without a RequestFunc per static
+// header. It may be given multiple times. Default headers are applied once
+// the request is encoded and before the before funcs run, so before funcs
+// always take precedence. If override is false, a header the
+// EncodeRequestFunc already set is kept; otherwise it's replaced.
+func ClientDefaultHeader[Req, Res any](key, value string, override bool) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) {
+		c.defaultHeaders = append(c.defaultHeaders, defaultHeader{key: key, value: value, override: override})
+	}
+}
+
 // ClientAfter adds one or more ClientResponseFuncs, which are applied to the
 // incoming HTTP response prior to it being decoded. This is useful for
 // obtaining anything off of the response and adding it into the context prior
@@ -70,6 +209,16 @@ func ClientAfter[Req, Res any](after ...ClientResponseFunc) ClientOption[Req, Re
 	return func(c *Client[Req, Res]) { c.after = append(c.after, after...) }
 }
 
+// ClientAfterDecode adds one or more ClientResponseFuncs, which are applied to
+// the incoming HTTP response once it has been successfully decoded. Unlike
+// ClientAfter funcs, they can read resp.Trailer: the rest of the body is
+// drained first, as trailers are only received at its end. For buffered
+// streams the body isn't drained, so trailers are only complete once the
+// caller has read it to EOF.
+func ClientAfterDecode[Req, Res any](after ...ClientResponseFunc) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.afterDecode = append(c.afterDecode, after...) }
+}
+
 // ClientFinalizer adds one or more ClientFinalizerFuncs to be executed at the
 // end of every HTTP request. Finalizers are executed in the order in which they
 // were added. By default, no finalizer is registered.
@@ -84,21 +233,195 @@ func BufferedStream[Req, Res any](buffered bool) ClientOption[Req, Res] {
 	return func(c *Client[Req, Res]) { c.bufferedStream = buffered }
 }
 
+// BufferedStreamIdleTimeout sets an idle detector for buffered streams: if no
+// bytes are read from the response body for d, e.g. because the server
+// stalled, the request context is canceled and the body is closed, and a
+// warning is logged. The timeout starts once the response is received and is reset
+// by every read returning data, so it also fires if the caller stops reading
+// without closing the body. It complements BufferedStreamMaxLifetime, which
+// bounds the whole stream. By default, buffered streams never time out.
+func BufferedStreamIdleTimeout[Req, Res any](d time.Duration) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.streamIdle = d }
+}
+
+// BufferedStreamMaxLifetime sets a safety net for buffered streams: if the
+// response body is still not closed after d, the request context is canceled,
+// which aborts any further read, and a warning is logged because the caller
+// most likely forgot to close the body. By default, buffered streams live
+// until they're closed.
+func BufferedStreamMaxLifetime[Req, Res any](d time.Duration) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.streamLifetime = d }
+}
+
+// ClientRetry makes the client re-issue a request up to max times while
+// retryable, which defaults to DefaultRetryable when nil, reports the
+// response or error of the last attempt as retryable. backoff gives the wait
+// before each retry, and a Retry-After header on the response takes precedence
+// over it. The encoded request body is captured so every attempt sends it in
+// full. A canceled context aborts the retries immediately. Before, after and
+// finalizer funcs run once per call, not per attempt. See RetryTransport to
+// share retries between clients.
+func ClientRetry[Req, Res any](max int, backoff BackoffFunc, retryable RetryableFunc) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) {
+		c.retry = func(next HTTPClient) HTTPClient {
+			return NewRetryTransport(next, max, backoff, retryable)
+		}
+	}
+}
+
+// Limiter models the Wait method of a rate limiter such as *rate.Limiter from
+// golang.org/x/time/rate. Wait blocks until a call is allowed, or returns an
+// error if ctx is done first or the wait would outlive its deadline.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// ClientRateLimit waits for limiter before sending every request, to stay
+// within the quota of a remote API. Each attempt of ClientRetry waits too, as
+// it's a request of its own to the API. If Wait fails, e.g. because the
+// context deadline is exceeded first, the endpoint returns its error without
+// sending the request, and finalizers still run.
+func ClientRateLimit[Req, Res any](limiter Limiter) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.limiter = limiter }
+}
+
+// limitedClient is an HTTPClient waiting for a Limiter before every request.
+type limitedClient struct {
+	next    HTTPClient
+	limiter Limiter
+}
+
+func (l limitedClient) Do(req *http.Request) (*http.Response, error) {
+	if err := l.limiter.Wait(req.Context()); err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	return l.next.Do(req)
+}
+
+// closeBody closes the body of a request that won't be sent, which the
+// HTTPClient would've closed otherwise, so a streaming body such as the one
+// of EncodeMultipartRequestStream is released.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// ClientCircuitBreaker sends every request through cb, so calls fail fast with
+// ErrCircuitOpen while the dependency is failing. Transport errors and 5xx
+// responses count as failures; the response of a failed call is still
+// decoded. With ClientRetry, the breaker sees each call once regardless of
+// how many attempts it took.
+func ClientCircuitBreaker[Req, Res any](cb CircuitBreaker) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.breaker = cb }
+}
+
+// ClientIdempotencyKey sends an idempotency key in header, Idempotency-Key if
+// empty, so that a server deduplicates retried requests, e.g. a POST charging
+// a card. The key is computed by gen once per invocation of the endpoint, and
+// sent on every attempt made by ClientRetry for that invocation only. The key
+// of the context, see gkit.IdempotencyKeyFromContext, is ignored, so a server
+// that received one doesn't send it on all of its distinct downstream calls.
+// A middleware such as gkit.Retry invokes the endpoint anew on each attempt,
+// and gets a fresh key each time.
+func ClientIdempotencyKey[Req, Res any](gen func(ctx context.Context, req Req) string, header string) ClientOption[Req, Res] {
+	if header == "" {
+		header = "Idempotency-Key"
+	}
+	return func(c *Client[Req, Res]) { c.idempotency, c.idempotencyHdr = gen, header }
+}
+
+// ClientTimeout bounds every call by d, from building the request to
+// decoding the response, for remote methods with their own SLA. A call
+// taking longer fails with context.DeadlineExceeded. For buffered streams
+// the deadline also covers reading the body, until it's closed. By default,
+// calls are only bounded by the caller's context.
+func ClientTimeout[Req, Res any](d time.Duration) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.timeout = d }
+}
+
+// ClientErrorDecoder sets a function run on every response, after the after
+// funcs but before the decoder, e.g. DecodeHTTPError(0). If it returns an
+// error, the endpoint returns that error without calling the decoder, so an
+// error body isn't decoded into the response type. It may read and close the
+// body itself. For buffered streams, the body is closed once it returns an
+// error, as the caller never gets the response. By default, every response is
+// handed to the decoder.
+func ClientErrorDecoder[Req, Res any](dec func(*http.Response) error) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.errorDecoder = dec }
+}
+
+// ClientGzip sets whether a response with a "Content-Encoding: gzip" header
+// has its body transparently decompressed before the after funcs and the
+// decoder see it, even when the request didn't ask for gzip. The header is
+// then removed and ContentLength set to -1, as the decompressed length is
+// unknown. A corrupt gzip stream surfaces as a read error in the decoder. See
+// the compress package for brotli and zstd.
+func ClientGzip[Req, Res any](enable bool) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.gzip = enable }
+}
+
+// Close stops the client from making new calls, which fail with
+// ErrClientClosed from then on. The bodies of buffered streams still open are
+// closed, aborting any further read, and Close waits for in-flight calls to
+// return until ctx is done, in which case ctx.Err() is returned. Close doesn't
+// cancel in-flight calls: they're bounded by their own contexts.
+func (c Client[Req, Res]) Close(ctx context.Context) error {
+	l := c.lifecycle
+	l.mu.Lock()
+	l.closed = true
+	streams := make([]*bodyWithCancel, 0, len(l.streams))
+	for body := range l.streams {
+		streams = append(streams, body)
+	}
+	l.mu.Unlock()
+
+	for _, body := range streams {
+		body.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Endpoint returns a usable Go kit endpoint that calls the remote HTTP endpoint.
 func (c Client[Req, Res]) Endpoint() gkit.Endpoint[Req, Res] {
 	return func(ctx context.Context, request Req) (Res, error) {
-		ctx, cancel := context.WithCancel(ctx)
-
 		var (
 			resp     *http.Response
 			response Res
 			err      error
 		)
+
+		if !c.lifecycle.begin() {
+			return response, ErrClientClosed
+		}
+		defer c.lifecycle.inflight.Done()
+
+		var cancel context.CancelFunc
+		if c.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
 		if c.finalizer != nil {
 			defer func() {
 				if resp != nil {
 					ctx = context.WithValue(ctx, ContextKeyResponseHeaders, resp.Header)
 					ctx = context.WithValue(ctx, ContextKeyResponseSize, resp.ContentLength)
+					ctx = context.WithValue(ctx, ContextKeyResponseTLS, resp.TLS)
+					ctx = context.WithValue(ctx, ContextKeyResponseTrailers, resp.Trailer)
 				}
 				for _, f := range c.finalizer {
 					f(ctx, err)
@@ -106,59 +429,222 @@ func (c Client[Req, Res]) Endpoint() gkit.Endpoint[Req, Res] {
 			}()
 		}
 
+		var idempotencyKey string
+		if c.idempotency != nil {
+			idempotencyKey = c.idempotency(ctx, request)
+		}
+
 		req, err := c.req(ctx, request)
 		if err != nil {
 			cancel()
 			return response, err
 		}
+		if idempotencyKey != "" {
+			req.Header.Set(c.idempotencyHdr, idempotencyKey)
+		}
+
+		for _, h := range c.defaultHeaders {
+			if h.override || req.Header.Get(h.key) == "" {
+				req.Header.Set(h.key, h.value)
+			}
+		}
 
 		for _, f := range c.before {
 			ctx = f(ctx, req)
 		}
 
-		resp, err = c.client.Do(req.WithContext(ctx))
+		client := c.client
+		if c.limiter != nil {
+			client = limitedClient{next: client, limiter: c.limiter}
+		}
+		if c.compression != nil {
+			client = &compressClient{next: client, n: c.compression}
+		}
+		if c.retry != nil {
+			if err = bufferBody(req); err != nil {
+				cancel()
+				return response, err
+			}
+			client = c.retry(client)
+		}
+		if c.breaker != nil {
+			client = breakerClient{next: client, cb: c.breaker}
+		}
+
+		resp, err = client.Do(req.WithContext(ctx))
 		if err != nil {
 			cancel()
 			return response, err
 		}
 
+		// Record read errors on the raw body, so a failure reading it can be
+		// told apart from a malformed payload once the decoder returns.
+		read := &readErrorBody{ReadCloser: resp.Body}
+		resp.Body = read
+
 		// If the caller asked for a buffered stream, we don't cancel the
 		// context when the endpoint returns. Instead, we should call the
 		// cancel func when closing the response body.
 		if c.bufferedStream {
-			resp.Body = bodyWithCancel{ReadCloser: resp.Body, cancel: cancel}
+			body := &bodyWithCancel{ReadCloser: resp.Body, cancel: cancel, idleTimeout: c.streamIdle}
+			// Track the body before the timers start, as they may close it.
+			c.lifecycle.track(body)
+			body.mu.Lock()
+			if c.streamLifetime > 0 {
+				body.timer = time.AfterFunc(c.streamLifetime, func() {
+					slog.WarnContext(ctx, "http: buffered stream body not closed before max lifetime, canceling request",
+						slog.String("url", req.URL.String()), slog.Duration("lifetime", c.streamLifetime))
+					cancel()
+				})
+			}
+			if c.streamIdle > 0 {
+				body.idle = time.AfterFunc(c.streamIdle, func() {
+					slog.WarnContext(ctx, "http: buffered stream body idle for too long, canceling request",
+						slog.String("url", req.URL.String()), slog.Duration("idle_timeout", c.streamIdle))
+					body.Close()
+				})
+			}
+			body.mu.Unlock()
+			resp.Body = body
 		} else {
 			defer resp.Body.Close()
 			defer cancel()
 		}
 
+		if c.gzip && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			resp.Body = &gzipBody{body: resp.Body}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			resp.Uncompressed = true
+		}
+
 		for _, f := range c.after {
 			ctx = f(ctx, resp)
 		}
 
+		if c.errorDecoder != nil {
+			if err = c.errorDecoder(resp); err != nil {
+				if c.bufferedStream {
+					resp.Body.Close()
+				}
+				return response, err
+			}
+		}
+
 		response, err = c.dec(ctx, resp)
 		if err != nil {
+			if read.err != nil && !errors.As(err, new(*BodyReadError)) {
+				err = &BodyReadError{Err: read.err}
+			}
 			return response, err
 		}
 
+		if len(c.afterDecode) > 0 {
+			if !c.bufferedStream {
+				io.Copy(io.Discard, resp.Body) //nolint:errcheck
+			}
+			for _, f := range c.afterDecode {
+				ctx = f(ctx, resp)
+			}
+		}
+
 		return response, nil
 	}
 }
 
+// readErrorBody is a wrapper for an io.ReadCloser which records the first
+// error other than io.EOF returned by Read.
+type readErrorBody struct {
+	io.ReadCloser
+
+	err error
+}
+
+func (b *readErrorBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
 // bodyWithCancel is a wrapper for an io.ReadCloser with also a
 // cancel function which is called when the Close is used
 type bodyWithCancel struct {
 	io.ReadCloser
 
-	cancel context.CancelFunc
+	cancel      context.CancelFunc
+	release     func()
+	idleTimeout time.Duration
+	closeOnce   sync.Once
+
+	mu    sync.Mutex // guards timer and idle, which a firing timer may read
+	timer *time.Timer
+	idle  *time.Timer
 }
 
-func (bwc bodyWithCancel) Close() error {
-	bwc.ReadCloser.Close()
-	bwc.cancel()
+func (bwc *bodyWithCancel) Read(p []byte) (int, error) {
+	n, err := bwc.ReadCloser.Read(p)
+	if n > 0 && bwc.idle != nil {
+		bwc.idle.Reset(bwc.idleTimeout)
+	}
+	return n, err
+}
+
+// Close is safe to call more than once, and concurrently, e.g. by the idle
+// timer, Client.Close and the caller.
+func (bwc *bodyWithCancel) Close() error {
+	bwc.closeOnce.Do(func() {
+		bwc.mu.Lock()
+		timer, idle := bwc.timer, bwc.idle
+		bwc.mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if idle != nil {
+			idle.Stop()
+		}
+		bwc.ReadCloser.Close()
+		bwc.cancel()
+		if bwc.release != nil {
+			bwc.release()
+		}
+	})
 	return nil
 }
 
+// gzipBody decompresses a gzip response body. The gzip header is only read on
+// the first Read, so a corrupt stream fails the decoder rather than the call.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (gb *gzipBody) Read(p []byte) (int, error) {
+	if gb.zr == nil && gb.err == nil {
+		gb.zr, gb.err = gzip.NewReader(gb.body)
+	}
+	if gb.err != nil {
+		return 0, gb.err
+	}
+	return gb.zr.Read(p)
+}
+
+func (gb *gzipBody) Close() error {
+	if gb.zr != nil {
+		gb.zr.Close()
+	}
+	return gb.body.Close()
+}
+
+// Unwrap returns the compressed body. See isBufferedStream.
+func (gb *gzipBody) Unwrap() io.ReadCloser {
+	return gb.body
+}
+
 // ClientFinalizerFunc can be used to perform work at the end of a client HTTP
 // request, after the response is returned. The principal
 // intended use is for error logging. Additional response parameters are
@@ -169,8 +655,11 @@ type ClientFinalizerFunc func(ctx context.Context, err error)
 
 // EncodeJSONRequest is an EncodeRequestFunc that serializes the request as a
 // JSON object to the Request body. Many JSON-over-HTTP services can use it as
-// a sensible default. TODO: If the request implements Headerer, the provided headers
+// a sensible default. If the request implements Headerer, the provided headers
 // will be applied to the request.
+//
+// The request is encoded before the body is set, so Content-Length is known,
+// and GetBody is set, so the body is replayed on redirects and retries.
 func EncodeJSONRequest[Req any](c context.Context, r *http.Request, request Req) error {
 	r.Header.Set("Content-Type", "application/json; charset=utf-8")
 
@@ -181,9 +670,121 @@ func EncodeJSONRequest[Req any](c context.Context, r *http.Request, request Req)
 	}
 
 	var b bytes.Buffer
-	r.Body = io.NopCloser(&b)
+	if err := json.NewEncoder(&b).Encode(request); err != nil {
+		return err
+	}
+
+	body := b.Bytes()
+	r.ContentLength = int64(len(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.Body, _ = r.GetBody()
+
+	return nil
+}
 
-	return json.NewEncoder(&b).Encode(request)
+// EncodeReaderRequest returns an EncodeRequestFunc sending the reader returned
+// by extract as the request body without buffering it, e.g. to proxy a large
+// upload, with the given Content-Type. The reader isn't closed. If it also
+// implements io.Seeker, Content-Length is found by seeking and GetBody rewinds
+// the reader to its current position, so the body is replayed on redirects
+// and retries. Otherwise the length is unknown, ContentLength stays -1 and the
+// body is sent chunked.
+func EncodeReaderRequest[Req any](contentType string, extract func(Req) io.Reader) EncodeRequestFunc[Req] {
+	return func(_ context.Context, r *http.Request, request Req) error {
+		body := extract(request)
+		r.Header.Set("Content-Type", contentType)
+		r.Body = io.NopCloser(body)
+		r.ContentLength = -1
+
+		seeker, ok := body.(io.Seeker)
+		if !ok {
+			return nil
+		}
+
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+
+		r.ContentLength = end - start
+		r.GetBody = func() (io.ReadCloser, error) {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(body), nil
+		}
+		return nil
+	}
+}
+
+// DecodeJSONResponse is a client DecodeResponseFunc that deserializes a JSON
+// response body to Res. Like DecodeXMLResponse, a non-2xx response is
+// returned as an *HTTPError instead. A body that fails to unmarshal is
+// reported with the status code of the response. The body is closed, unless
+// the Client was created with BufferedStream, in which case closing it is
+// left to the caller.
+func DecodeJSONResponse[Res any](ctx context.Context, resp *http.Response) (Res, error) {
+	return DecodeJSONResponseWith[Res]()(ctx, resp)
+}
+
+// DecodeJSONResponseStrict is like DecodeJSONResponse, but fails when the
+// body has fields unknown to Res, to catch drift between the schemas of the
+// client and the server. A non-2xx response is returned as an *HTTPError.
+func DecodeJSONResponseStrict[Res any](ctx context.Context, resp *http.Response) (Res, error) {
+	return DecodeJSONResponseWith[Res](gkit.DisallowUnknownFields)(ctx, resp)
+}
+
+// DecodeJSONResponseWith returns a DecodeResponseFunc like DecodeJSONResponse
+// whose json.Decoder is configured with options, e.g. gkit.UseNumber. A
+// non-2xx response is returned as an *HTTPError, decoded with DecodeHTTPError,
+// and its body isn't unmarshaled into Res.
+func DecodeJSONResponseWith[Res any](options ...gkit.JSONDecoderOption) gkit.EncodeDecodeFunc[*http.Response, Res] {
+	return func(_ context.Context, resp *http.Response) (Res, error) {
+		var res Res
+		if !isBufferedStream(resp.Body) {
+			defer resp.Body.Close()
+		}
+
+		if err := DecodeHTTPError(DefaultMaxErrorBodySize)(resp); err != nil {
+			return res, err
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		for _, option := range options {
+			option(dec)
+		}
+
+		if err := dec.Decode(&res); err != nil {
+			return res, fmt.Errorf("http: decoding %d %s response: %w", resp.StatusCode, http.StatusText(resp.StatusCode), err)
+		}
+
+		return res, nil
+	}
+}
+
+// isBufferedStream reports whether body is the body of a buffered stream. A
+// body wrapped by a ClientResponseFunc, e.g. to decompress it, is unwrapped
+// if the wrapper has an Unwrap method returning the body it wraps.
+func isBufferedStream(body io.ReadCloser) bool {
+	for {
+		switch b := body.(type) {
+		case *bodyWithCancel:
+			return true
+		case interface{ Unwrap() io.ReadCloser }:
+			body = b.Unwrap()
+		default:
+			return false
+		}
+	}
 }
 
 func makeCreateRequestFunc[Req any](method string, target *url.URL, enc EncodeRequestFunc[Req]) gkit.EncodeDecodeFunc[Req, *http.Request] {
@@ -200,3 +801,60 @@ func makeCreateRequestFunc[Req any](method string, target *url.URL, enc EncodeRe
 		return req, nil
 	}
 }
+
+func makePathRequestFunc[Req any](method, pathTemplate string, base *url.URL, extract func(Req) map[string]string, enc EncodeRequestFunc[Req]) gkit.EncodeDecodeFunc[Req, *http.Request] {
+	return func(ctx context.Context, request Req) (*http.Request, error) {
+		path, rawPath, err := expandPath(pathTemplate, extract(request))
+		if err != nil {
+			return nil, err
+		}
+
+		target := *base
+		target.Path = strings.TrimSuffix(base.Path, "/") + path
+		target.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + rawPath
+
+		return makeCreateRequestFunc(method, &target, enc)(ctx, request)
+	}
+}
+
+func makeResolvedRequestFunc[Req any](method string, resolve func(context.Context) (*url.URL, error), enc EncodeRequestFunc[Req]) gkit.EncodeDecodeFunc[Req, *http.Request] {
+	return func(ctx context.Context, request Req) (*http.Request, error) {
+		target, err := resolve(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrResolveTarget, err)
+		}
+
+		return makeCreateRequestFunc(method, target, enc)(ctx, request)
+	}
+}
+
+// expandPath replaces the {name} placeholders of tmpl with values, returning
+// both the unescaped and the escaped path. An unclosed brace is kept as is.
+func expandPath(tmpl string, values map[string]string) (path, rawPath string, err error) {
+	var p, raw strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		end := strings.IndexByte(tmpl[max(start, 0):], '}')
+		if start < 0 || end < 0 {
+			break
+		}
+		end += start
+
+		p.WriteString(tmpl[:start])
+		raw.WriteString(tmpl[:start])
+
+		name := tmpl[start+1 : end]
+		value := values[name]
+		if value == "" {
+			return "", "", fmt.Errorf("%w for {%s} in %q", ErrMissingPathValue, name, tmpl)
+		}
+		p.WriteString(value)
+		raw.WriteString(url.PathEscape(value))
+
+		tmpl = tmpl[end+1:]
+	}
+	p.WriteString(tmpl)
+	raw.WriteString(tmpl)
+
+	return p.String(), raw.String(), nil
+}
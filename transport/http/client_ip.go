@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client that sent r. When the direct
+// peer, r.RemoteAddr, belongs to one of trustedProxies, the X-Forwarded-For
+// header is walked from the right, skipping trusted proxies, and the first
+// address that isn't trusted is returned; without that header, X-Real-IP is
+// used. Headers of untrusted peers are ignored, since anybody can send them,
+// so with no trusted proxies the result is always the peer address. It
+// returns nil if no valid address is found.
+func ClientIP(r *http.Request, trustedProxies []net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote := net.ParseIP(host)
+	if remote == nil || !trusted(remote, trustedProxies) {
+		return remote
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			client = ip
+			if !trusted(ip, trustedProxies) {
+				break
+			}
+		}
+		return client
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip
+	}
+	return remote
+}
+
+func trusted(ip net.IP, proxies []net.IPNet) bool {
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// PopulateClientIP returns a RequestFunc, usable with ServerBefore, that
+// stores the address returned by ClientIP in the context under
+// ContextKeyRequestClientIP. Use ClientIPFromContext to read it.
+func PopulateClientIP(trustedProxies []net.IPNet) func(context.Context, *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		return context.WithValue(ctx, ContextKeyRequestClientIP, ClientIP(r, trustedProxies))
+	}
+}
+
+// ClientIPFromContext returns the client address stored by PopulateClientIP,
+// or nil if there is none.
+func ClientIPFromContext(ctx context.Context) net.IP {
+	ip, _ := ctx.Value(ContextKeyRequestClientIP).(net.IP)
+	return ip
+}
@@ -0,0 +1,59 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []net.IPNet{*proxies}
+
+	for _, tc := range []struct {
+		name    string
+		remote  string
+		xff     []string
+		realIP  string
+		trusted []net.IPNet
+		want    string
+	}{
+		{"direct", "203.0.113.7:1234", nil, "", trusted, "203.0.113.7"},
+		{"spoofed by untrusted peer", "203.0.113.7:1234", []string{"1.2.3.4"}, "5.6.7.8", trusted, "203.0.113.7"},
+		{"no trusted proxies", "10.0.0.1:1234", []string{"1.2.3.4"}, "", nil, "10.0.0.1"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"198.51.100.2"}, "", trusted, "198.51.100.2"},
+		{"proxy chain", "10.0.0.1:1234", []string{"6.6.6.6, 198.51.100.2", "10.0.0.2"}, "", trusted, "198.51.100.2"},
+		{"all trusted", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "", trusted, "10.0.0.3"},
+		{"real ip", "10.0.0.1:1234", nil, "198.51.100.9", trusted, "198.51.100.9"},
+		{"ipv6", "[2001:db8::1]:443", nil, "", trusted, "2001:db8::1"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tc.remote
+		for _, v := range tc.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if tc.realIP != "" {
+			r.Header.Set("X-Real-IP", tc.realIP)
+		}
+
+		if have := httptransport.ClientIP(r, tc.trusted); !net.ParseIP(tc.want).Equal(have) {
+			t.Errorf("%s: want %s, have %s", tc.name, tc.want, have)
+		}
+	}
+}
+
+func TestPopulateClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+
+	ctx := httptransport.PopulateClientIP(nil)(context.Background(), r)
+	if want, have := net.ParseIP("203.0.113.7"), httptransport.ClientIPFromContext(ctx); !want.Equal(have) {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
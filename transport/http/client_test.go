@@ -4,16 +4,23 @@ package http_test
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	gkit "github.com/bobobox-id/gkit/core"
 	httptransport "github.com/bobobox-id/gkit/transport/http"
 )
 
@@ -341,3 +348,1099 @@ type httpClientFunc func(req *http.Request) (*http.Response, error)
 func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+func TestClientContextAcrossRetries(t *testing.T) {
+	type ctxKey string
+
+	var (
+		attempts   int
+		requestIDs []string
+		leaked     []bool
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		"GET",
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, r *http.Response) (struct{}, error) {
+			if r.StatusCode != http.StatusOK {
+				return struct{}{}, fmt.Errorf("status %d", r.StatusCode)
+			}
+			return struct{}{}, nil
+		},
+		httptransport.ClientBefore[struct{}, struct{}](func(ctx context.Context, r *http.Request) context.Context {
+			requestID, _ := ctx.Value(ctxKey("request-id")).(string)
+			requestIDs = append(requestIDs, requestID)
+			_, ok := ctx.Value(ctxKey("attempt")).(bool)
+			leaked = append(leaked, ok)
+			return context.WithValue(ctx, ctxKey("attempt"), true)
+		}),
+	)
+
+	endpoint := gkit.Retry[struct{}, struct{}](5, nil, nil)(client.Endpoint())
+
+	ctx := context.WithValue(context.Background(), ctxKey("request-id"), "a1b2c3")
+	if _, err := endpoint(ctx, struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := 3, attempts; want != have {
+		t.Fatalf("attempts: want %d, have %d", want, have)
+	}
+	for i := range requestIDs {
+		if want, have := "a1b2c3", requestIDs[i]; want != have {
+			t.Errorf("attempt %d: request ID: want %q, have %q", i, want, have)
+		}
+		if leaked[i] {
+			t.Errorf("attempt %d: context value leaked from previous attempt", i)
+		}
+	}
+}
+
+func TestClientFinalizerTLSState(t *testing.T) {
+	var (
+		encode = func(context.Context, *http.Request, struct{}) error { return nil }
+		decode = func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil }
+	)
+
+	for _, newServer := range []func(http.Handler) *httptest.Server{httptest.NewServer, httptest.NewTLSServer} {
+		server := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		var state *tls.ConnectionState
+		client := httptransport.NewClient(
+			"GET",
+			mustParse(server.URL),
+			encode,
+			decode,
+			httptransport.SetClient[struct{}, struct{}](server.Client()),
+			httptransport.ClientFinalizer[struct{}, struct{}](func(ctx context.Context, err error) {
+				state = httptransport.TLSStateFromContext(ctx)
+			}),
+		)
+
+		if _, err := client.Endpoint()(context.Background(), struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if want, have := server.TLS != nil, state != nil; want != have {
+			t.Errorf("%s: want TLS state %v, have %v", server.URL, want, have)
+		}
+		if state != nil && state.Version < tls.VersionTLS12 {
+			t.Errorf("unexpected TLS version %x", state.Version)
+		}
+	}
+}
+
+func TestHTTPClientBufferedStreamMaxLifetime(t *testing.T) {
+	var (
+		encode = func(context.Context, *http.Request, struct{}) error { return nil }
+		decode = func(_ context.Context, r *http.Response) (TestResponse, error) {
+			return TestResponse{r.Body, ""}, nil
+		}
+		written = make(chan struct{})
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		close(written)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient[struct{}, TestResponse](
+		"GET",
+		mustParse(server.URL),
+		encode,
+		decode,
+		httptransport.BufferedStream[struct{}, TestResponse](true),
+		httptransport.BufferedStreamMaxLifetime[struct{}, TestResponse](50*time.Millisecond),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	<-written
+
+	// The body is deliberately left open: the lifetime must cancel it, so
+	// reading until the end fails instead of blocking forever.
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(res.Body)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("want error after max lifetime, have none")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the stream to be canceled")
+	}
+}
+
+func TestHTTPClientBufferedStreamIdleTimeout(t *testing.T) {
+	var (
+		encode = func(context.Context, *http.Request, struct{}) error { return nil }
+		decode = func(_ context.Context, r *http.Response) (TestResponse, error) {
+			return TestResponse{r.Body, ""}, nil
+		}
+	)
+
+	// The server keeps sending for longer than the idle timeout, then stalls.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient[struct{}, TestResponse](
+		"GET",
+		mustParse(server.URL),
+		encode,
+		decode,
+		httptransport.BufferedStream[struct{}, TestResponse](true),
+		httptransport.BufferedStreamIdleTimeout[struct{}, TestResponse](60*time.Millisecond),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		b, err := io.ReadAll(res.Body)
+		done <- result{b, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err == nil {
+			t.Error("want error after idle timeout, have none")
+		}
+		if want, have := strings.Repeat("chunk", 5), string(r.body); want != have {
+			t.Errorf("want %q read before the stall, have %q", want, have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the stream to be canceled")
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	var (
+		encode = func(context.Context, *http.Request, struct{}) error { return nil }
+		decode = func(_ context.Context, r *http.Response) (TestResponse, error) {
+			return TestResponse{r.Body, ""}, nil
+		}
+		written = make(chan struct{})
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		close(written)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient[struct{}, TestResponse](
+		"GET",
+		mustParse(server.URL),
+		encode,
+		decode,
+		httptransport.BufferedStream[struct{}, TestResponse](true),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-written
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream left open must have been closed by Close.
+	if _, err := io.ReadAll(res.Body); err == nil {
+		t.Error("want error reading a stream after Close, have none")
+	}
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); !errors.Is(err, httptransport.ErrClientClosed) {
+		t.Errorf("want %v, have %v", httptransport.ErrClientClosed, err)
+	}
+}
+
+func TestClientCloseConcurrentStreamClose(t *testing.T) {
+	var (
+		encode = func(context.Context, *http.Request, struct{}) error { return nil }
+		decode = func(_ context.Context, r *http.Response) (TestResponse, error) {
+			return TestResponse{r.Body, ""}, nil
+		}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	// The idle timer fires right away, racing with the callers closing the
+	// stream below.
+	client := httptransport.NewClient[struct{}, TestResponse](
+		"GET",
+		mustParse(server.URL),
+		encode,
+		decode,
+		httptransport.BufferedStream[struct{}, TestResponse](true),
+		httptransport.BufferedStreamIdleTimeout[struct{}, TestResponse](time.Nanosecond),
+		httptransport.BufferedStreamMaxLifetime[struct{}, TestResponse](time.Nanosecond),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res.Body.Close()
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	wg.Wait()
+}
+
+func TestClientCloseWaitsForInflight(t *testing.T) {
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	client := httptransport.NewClient[struct{}, struct{}](
+		"GET",
+		mustParse("http://example.invalid"),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.SetClient[struct{}, struct{}](httpClientFunc(func(*http.Request) (*http.Response, error) {
+			close(started)
+			<-release
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})),
+	)
+
+	go client.Endpoint()(context.Background(), struct{}{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("in flight: want %v, have %v", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	if err := client.Close(context.Background()); err != nil {
+		t.Errorf("drained: want no error, have %v", err)
+	}
+}
+
+func TestClientGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.URL.Query().Get("corrupt") != "" {
+			w.Write([]byte("not gzip"))
+			return
+		}
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte("testbody"))
+		zw.Close()
+	}))
+	defer server.Close()
+
+	decode := func(_ context.Context, r *http.Response) (string, error) {
+		if enc := r.Header.Get("Content-Encoding"); enc != "" {
+			t.Errorf("Content-Encoding: want none, have %q", enc)
+		}
+		b, err := io.ReadAll(r.Body)
+		return string(b), err
+	}
+
+	for _, buffered := range []bool{false, true} {
+		// A request header keeps the standard library from negotiating and
+		// decompressing gzip itself.
+		client := httptransport.NewClient[struct{}, string](
+			"GET",
+			mustParse(server.URL),
+			func(_ context.Context, r *http.Request, _ struct{}) error {
+				r.Header.Set("Accept-Encoding", "identity")
+				return nil
+			},
+			decode,
+			httptransport.ClientGzip[struct{}, string](true),
+			httptransport.BufferedStream[struct{}, string](buffered),
+		)
+
+		have, err := client.Endpoint()(context.Background(), struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "testbody"; want != have {
+			t.Errorf("buffered %v: want %q, have %q", buffered, want, have)
+		}
+	}
+
+	client := httptransport.NewClient[struct{}, string](
+		"GET",
+		mustParse(server.URL+"?corrupt=1"),
+		func(_ context.Context, r *http.Request, _ struct{}) error {
+			r.Header.Set("Accept-Encoding", "identity")
+			return nil
+		},
+		decode,
+		httptransport.ClientGzip[struct{}, string](true),
+	)
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); err == nil {
+		t.Error("corrupt stream: want decode error, have none")
+	}
+}
+
+// wrappedBody stands for a body wrapped by a ClientResponseFunc, e.g. to
+// decompress it.
+type wrappedBody struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (b *wrappedBody) Close() error {
+	b.closed = true
+	return b.ReadCloser.Close()
+}
+
+func (b *wrappedBody) Unwrap() io.ReadCloser { return b.ReadCloser }
+
+func TestDecodeJSONResponseBufferedStreamWrapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	var body *wrappedBody
+	client := httptransport.NewClient(
+		"GET",
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		httptransport.DecodeJSONResponse[struct{ ID int }],
+		httptransport.BufferedStream[struct{}, struct{ ID int }](true),
+		httptransport.ClientAfter[struct{}, struct{ ID int }](func(ctx context.Context, r *http.Response) context.Context {
+			body = &wrappedBody{ReadCloser: r.Body}
+			r.Body = body
+			return ctx
+		}),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, res.ID; want != have {
+		t.Errorf("ID: want %d, have %d", want, have)
+	}
+	if body.closed {
+		t.Error("want the body of a buffered stream left open, have it closed")
+	}
+	body.Close()
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient[struct{}, struct{}](
+		"GET",
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientTimeout[struct{}, struct{}](20*time.Millisecond),
+	)
+
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClientTimeoutBufferedStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient[struct{}, TestResponse](
+		"GET",
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, r *http.Response) (TestResponse, error) { return TestResponse{r.Body, ""}, nil },
+		httptransport.BufferedStream[struct{}, TestResponse](true),
+		httptransport.ClientTimeout[struct{}, TestResponse](50*time.Millisecond),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// The endpoint has returned, yet the body stays readable until the
+	// deadline, which then aborts the stream.
+	buf := make([]byte, len("first chunk"))
+	if _, err := io.ReadFull(res.Body, buf); err != nil {
+		t.Fatalf("want the first chunk after the endpoint returned, have %v", err)
+	}
+	if _, err := io.ReadAll(res.Body); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClientErrorDecoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"boom"}`))
+			return
+		}
+		w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+
+	for _, buffered := range []bool{false, true} {
+		var decoded int
+		decode := func(_ context.Context, r *http.Response) (string, error) {
+			decoded++
+			b, err := io.ReadAll(r.Body)
+			return string(b), err
+		}
+
+		for path, wantErr := range map[string]bool{"/": false, "/?fail=1": true} {
+			client := httptransport.NewClient[struct{}, string](
+				"GET",
+				mustParse(server.URL+path),
+				func(context.Context, *http.Request, struct{}) error { return nil },
+				decode,
+				httptransport.ClientErrorDecoder[struct{}, string](httptransport.DecodeHTTPError(0)),
+				httptransport.BufferedStream[struct{}, string](buffered),
+			)
+
+			_, err := client.Endpoint()(context.Background(), struct{}{})
+			var httpErr *httptransport.HTTPError
+			if wantErr != errors.As(err, &httpErr) {
+				t.Errorf("buffered %v, %s: want *HTTPError %v, have %v", buffered, path, wantErr, err)
+			}
+		}
+		if want := 1; want != decoded {
+			t.Errorf("buffered %v: want %d decoded response, have %d", buffered, want, decoded)
+		}
+	}
+}
+
+func TestClientBodyReadError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("malformed") != "" {
+			w.Write([]byte(`{"name":`))
+			return
+		}
+		// Promise more bytes than are written, so the connection is closed
+		// mid-body.
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"name":"trunc`))
+	}))
+	defer server.Close()
+
+	decode := func(_ context.Context, r *http.Response) (map[string]string, error) {
+		var res map[string]string
+		err := json.NewDecoder(r.Body).Decode(&res)
+		return res, err
+	}
+
+	for path, want := range map[string]bool{"/": true, "/?malformed=1": false} {
+		client := httptransport.NewClient[struct{}, map[string]string](
+			"GET",
+			mustParse(server.URL+path),
+			func(context.Context, *http.Request, struct{}) error { return nil },
+			decode,
+		)
+
+		_, err := client.Endpoint()(context.Background(), struct{}{})
+		if err == nil {
+			t.Fatalf("%s: want error, have none", path)
+		}
+
+		var readErr *httptransport.BodyReadError
+		if have := errors.As(err, &readErr); want != have {
+			t.Errorf("%s: want *BodyReadError %v, have %v", path, want, err)
+		}
+		if want && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: want %v wrapped, have %v", path, io.ErrUnexpectedEOF, err)
+		}
+	}
+}
+
+func TestNewPathClient(t *testing.T) {
+	type orderRequest struct {
+		UserID, OrderID string
+	}
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+	}))
+	defer server.Close()
+
+	client := httptransport.NewPathClient(
+		http.MethodGet,
+		"/users/{id}/orders/{orderID}",
+		mustParse(server.URL+"/api/"),
+		func(req orderRequest) map[string]string {
+			return map[string]string{"id": req.UserID, "orderID": req.OrderID}
+		},
+		func(context.Context, *http.Request, orderRequest) error { return nil },
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+	)
+
+	if _, err := client.Endpoint()(context.Background(), orderRequest{UserID: "a/b c", OrderID: "7"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Endpoint()(context.Background(), orderRequest{UserID: "a"}); !errors.Is(err, httptransport.ErrMissingPathValue) {
+		t.Errorf("want %v, have %v", httptransport.ErrMissingPathValue, err)
+	}
+
+	if want, have := []string{"/api/users/a%2Fb%20c/orders/7"}, paths; len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want requests to %v, have %v", want, have)
+	}
+}
+
+func TestNewClientResolved(t *testing.T) {
+	var hits [2]int
+	servers := make([]*httptest.Server, 2)
+	for i := range servers {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits[i]++ }))
+		defer servers[i].Close()
+	}
+
+	var (
+		active      = 0
+		errResolver = errors.New("no healthy target")
+	)
+	client := httptransport.NewClientResolved(
+		http.MethodGet,
+		func(context.Context) (*url.URL, error) {
+			if active < 0 {
+				return nil, errResolver
+			}
+			return url.Parse(servers[active].URL)
+		},
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+	)
+
+	for _, target := range []int{0, 1, 1} {
+		active = target
+		if _, err := client.Endpoint()(context.Background(), struct{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := [2]int{1, 2}, hits; want != have {
+		t.Errorf("want hits %v, have %v", want, have)
+	}
+
+	active = -1
+	_, err := client.Endpoint()(context.Background(), struct{}{})
+	if !errors.Is(err, httptransport.ErrResolveTarget) || !errors.Is(err, errResolver) {
+		t.Errorf("want %v wrapping %v, have %v", httptransport.ErrResolveTarget, errResolver, err)
+	}
+}
+
+func TestClientCookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		default:
+			if c, err := r.Cookie("session"); err != nil || c.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		noop   = func(context.Context, *http.Request, struct{}) error { return nil }
+		status = func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil }
+		login  = httptransport.NewClient(http.MethodPost, mustParse(server.URL+"/login"), noop, status, httptransport.ClientCookieJar[struct{}, int](jar))
+		me     = httptransport.NewClient(http.MethodGet, mustParse(server.URL+"/me"), noop, status, httptransport.ClientCookieJar[struct{}, int](jar))
+	)
+
+	if code, err := me.Endpoint()(context.Background(), struct{}{}); err != nil || code != http.StatusUnauthorized {
+		t.Errorf("before login: want %d, have %d, %v", http.StatusUnauthorized, code, err)
+	}
+	if _, err := login.Endpoint()(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if code, err := me.Endpoint()(context.Background(), struct{}{}); err != nil || code != http.StatusOK {
+		t.Errorf("after login: want %d, have %d, %v", http.StatusOK, code, err)
+	}
+	if http.DefaultClient.Jar != nil {
+		t.Error("http.DefaultClient jar was modified")
+	}
+}
+
+func TestClientCheckRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var redirected int
+	for name, tc := range map[string]struct {
+		policy func(*http.Request, []*http.Request) error
+		want   int
+	}{
+		"followed": {func(*http.Request, []*http.Request) error { redirected++; return nil }, http.StatusOK},
+		"blocked":  {func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }, http.StatusMovedPermanently},
+	} {
+		client := httptransport.NewClient(
+			http.MethodGet,
+			mustParse(server.URL+"/old"),
+			func(context.Context, *http.Request, struct{}) error { return nil },
+			func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+			httptransport.ClientCheckRedirect[struct{}, int](tc.policy),
+		)
+
+		code, err := client.Endpoint()(context.Background(), struct{}{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if code != tc.want {
+			t.Errorf("%s: want %d, have %d", name, tc.want, code)
+		}
+	}
+
+	if want, have := 1, redirected; want != have {
+		t.Errorf("want policy called %d time, have %d", want, have)
+	}
+	if http.DefaultClient.CheckRedirect != nil {
+		t.Error("http.DefaultClient modified")
+	}
+}
+
+// countingBreaker opens after a single failure.
+type countingBreaker struct {
+	failures, successes int
+}
+
+func (b *countingBreaker) Execute(req func() (any, error)) (any, error) {
+	if b.failures > 0 {
+		return nil, errors.New("breaker is open")
+	}
+	v, err := req()
+	if err != nil {
+		b.failures++
+	} else {
+		b.successes++
+	}
+	return v, err
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		cb        = &countingBreaker{}
+		finalized []error
+	)
+	newClient := func(path string) *httptransport.Client[struct{}, int] {
+		return httptransport.NewClient(
+			http.MethodGet,
+			mustParse(server.URL+path),
+			func(context.Context, *http.Request, struct{}) error { return nil },
+			func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+			httptransport.ClientCircuitBreaker[struct{}, int](cb),
+			httptransport.ClientFinalizer[struct{}, int](func(_ context.Context, err error) { finalized = append(finalized, err) }),
+		)
+	}
+
+	if code, err := newClient("/").Endpoint()(context.Background(), struct{}{}); err != nil || code != http.StatusOK {
+		t.Fatalf("want 200, have %d, %v", code, err)
+	}
+	if code, err := newClient("/?fail=1").Endpoint()(context.Background(), struct{}{}); err != nil || code != http.StatusServiceUnavailable {
+		t.Fatalf("want 503 decoded, have %d, %v", code, err)
+	}
+	if _, err := newClient("/").Endpoint()(context.Background(), struct{}{}); !errors.Is(err, httptransport.ErrCircuitOpen) {
+		t.Errorf("want %v, have %v", httptransport.ErrCircuitOpen, err)
+	}
+
+	if want, have := 2, requests; want != have {
+		t.Errorf("want %d requests sent, have %d", want, have)
+	}
+	if cb.successes != 1 || cb.failures != 1 {
+		t.Errorf("want 1 success and 1 failure recorded, have %d and %d", cb.successes, cb.failures)
+	}
+	if want, have := 3, len(finalized); want != have || !errors.Is(finalized[2], httptransport.ErrCircuitOpen) {
+		t.Errorf("want the finalizer called with the breaker error, have %v", finalized)
+	}
+
+	body := &closeRecorder{Reader: strings.NewReader("payload")}
+	_, err := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL),
+		func(_ context.Context, r *http.Request, _ struct{}) error { r.Body = body; return nil },
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientCircuitBreaker[struct{}, struct{}](cb),
+	).Endpoint()(context.Background(), struct{}{})
+	if !errors.Is(err, httptransport.ErrCircuitOpen) || !body.closed {
+		t.Errorf("want %v and the body closed, have %v and closed %v", httptransport.ErrCircuitOpen, err, body.closed)
+	}
+}
+
+func TestEncodeJSONRequestRedirect(t *testing.T) {
+	var (
+		received      string
+		contentLength int64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		received, contentLength = string(b), r.ContentLength
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL+"/old"),
+		httptransport.EncodeJSONRequest[map[string]string],
+		func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+	)
+
+	code, err := client.Endpoint()(context.Background(), map[string]string{"name": "gkit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusOK, code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "{\"name\":\"gkit\"}\n", received; want != have {
+		t.Errorf("body: want %q, have %q", want, have)
+	}
+	if want, have := int64(len(received)), contentLength; want != have {
+		t.Errorf("Content-Length: want %d, have %d", want, have)
+	}
+}
+
+func TestDecodeJSONResponse(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/extra":
+			w.Write([]byte(`{"name":"ann","age":30}`))
+		case "/malformed":
+			w.Write([]byte(`{"name":`))
+		case "/missing":
+			http.Error(w, "no such user", http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"name":"ann"}`))
+		}
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path   string
+		dec    func(context.Context, *http.Response) (user, error)
+		errMsg string
+	}{
+		{"/", httptransport.DecodeJSONResponse[user], ""},
+		{"/extra", httptransport.DecodeJSONResponse[user], ""},
+		{"/extra", httptransport.DecodeJSONResponseStrict[user], `unknown field "age"`},
+		{"/malformed", httptransport.DecodeJSONResponse[user], "200 OK"},
+		{"/missing", httptransport.DecodeJSONResponse[user], "404"},
+	} {
+		client := httptransport.NewClient(
+			http.MethodGet,
+			mustParse(server.URL+tc.path),
+			func(context.Context, *http.Request, struct{}) error { return nil },
+			tc.dec,
+		)
+
+		have, err := client.Endpoint()(context.Background(), struct{}{})
+		if tc.errMsg == "" {
+			if err != nil || have.Name != "ann" {
+				t.Errorf("%s: want ann, have %+v, %v", tc.path, have, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+			t.Errorf("%s: want error containing %q, have %v", tc.path, tc.errMsg, err)
+		}
+	}
+}
+
+// tokenLimiter is a Limiter handing out a fixed number of tokens, then
+// failing.
+type tokenLimiter struct {
+	tokens, waits int
+}
+
+func (l *tokenLimiter) Wait(context.Context) error {
+	l.waits++
+	if l.tokens == 0 {
+		return errors.New("out of tokens")
+	}
+	l.tokens--
+	return nil
+}
+
+func TestClientRateLimit(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var (
+		finalized []error
+		body      *closeRecorder
+		limiter   = &tokenLimiter{tokens: 2}
+	)
+	client := httptransport.NewClient(
+		http.MethodPut,
+		mustParse(server.URL),
+		func(_ context.Context, r *http.Request, _ struct{}) error {
+			body = &closeRecorder{Reader: strings.NewReader("payload")}
+			r.Body = body
+			return nil
+		},
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientRetry[struct{}, struct{}](3, nil, nil),
+		httptransport.ClientRateLimit[struct{}, struct{}](limiter),
+		httptransport.ClientFinalizer[struct{}, struct{}](func(_ context.Context, err error) { finalized = append(finalized, err) }),
+	)
+
+	// The retried attempt takes a token of its own.
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, limiter.waits; want != have {
+		t.Errorf("want %d waits, have %d", want, have)
+	}
+
+	client = httptransport.NewClient(
+		http.MethodPut,
+		mustParse(server.URL),
+		func(_ context.Context, r *http.Request, _ struct{}) error {
+			body = &closeRecorder{Reader: strings.NewReader("payload")}
+			r.Body = body
+			return nil
+		},
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientRateLimit[struct{}, struct{}](limiter),
+		httptransport.ClientFinalizer[struct{}, struct{}](func(_ context.Context, err error) { finalized = append(finalized, err) }),
+	)
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); err == nil {
+		t.Error("want error waiting for the limiter, have none")
+	}
+	if !body.closed {
+		t.Error("want the body of the unsent request closed")
+	}
+
+	if want, have := 2, requests; want != have {
+		t.Errorf("want %d requests sent, have %d", want, have)
+	}
+	if want, have := 2, len(finalized); want != have || finalized[1] == nil {
+		t.Errorf("want the finalizer called with the limiter error, have %v", finalized)
+	}
+}
+
+func TestEncodeReaderRequest(t *testing.T) {
+	type received struct {
+		body, contentType string
+		contentLength     int64
+	}
+
+	var have received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		have = received{string(b), r.Header.Get("Content-Type"), r.ContentLength}
+	}))
+	defer server.Close()
+
+	newClient := func(path string) *httptransport.Client[io.Reader, int] {
+		return httptransport.NewClient(
+			http.MethodPut,
+			mustParse(server.URL+path),
+			httptransport.EncodeReaderRequest("text/csv", func(r io.Reader) io.Reader { return r }),
+			func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+		)
+	}
+
+	// A seeker is replayed after the redirect, with a known length.
+	seeker := strings.NewReader("skip,a,b")
+	seeker.Seek(5, io.SeekStart)
+	if code, err := newClient("/old").Endpoint()(context.Background(), seeker); err != nil || code != http.StatusOK {
+		t.Fatalf("want 200, have %d, %v", code, err)
+	}
+	if want := (received{"a,b", "text/csv", 3}); want != have {
+		t.Errorf("seeker: want %+v, have %+v", want, have)
+	}
+
+	// A plain reader is streamed with an unknown length.
+	if _, err := newClient("/new").Endpoint()(context.Background(), io.MultiReader(strings.NewReader("c,d"))); err != nil {
+		t.Fatal(err)
+	}
+	if want := (received{"c,d", "text/csv", -1}); want != have {
+		t.Errorf("reader: want %+v, have %+v", want, have)
+	}
+}
+
+func TestClientAfterDecodeTrailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.Write([]byte(`"ok"` + "\n\n"))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	defer server.Close()
+
+	var afterDecode, finalized string
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		// Decoding stops after the value, before the end of the body.
+		func(_ context.Context, r *http.Response) (string, error) {
+			var s string
+			err := json.NewDecoder(io.LimitReader(r.Body, 4)).Decode(&s)
+			return s, err
+		},
+		httptransport.ClientAfterDecode[struct{}, string](func(ctx context.Context, r *http.Response) context.Context {
+			afterDecode = r.Trailer.Get("Grpc-Status")
+			return ctx
+		}),
+		httptransport.ClientFinalizer[struct{}, string](func(ctx context.Context, err error) {
+			trailer, _ := ctx.Value(httptransport.ContextKeyResponseTrailers).(http.Header)
+			finalized = trailer.Get("Grpc-Status")
+		}),
+	)
+
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0"; want != afterDecode {
+		t.Errorf("after decode: want trailer %q, have %q", want, afterDecode)
+	}
+	if want := "0"; want != finalized {
+		t.Errorf("finalizer: want trailer %q, have %q", want, finalized)
+	}
+}
+
+func TestClientDefaultHeader(t *testing.T) {
+	var have http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		have = r.Header
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse(server.URL),
+		func(_ context.Context, r *http.Request, _ struct{}) error {
+			r.Header.Set("X-Kept", "encoder")
+			r.Header.Set("X-Replaced", "encoder")
+			r.Header.Set("X-Before", "encoder")
+			return nil
+		},
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientDefaultHeader[struct{}, struct{}]("User-Agent", "gkit-test/1.0", false),
+		httptransport.ClientDefaultHeader[struct{}, struct{}]("X-Kept", "default", false),
+		httptransport.ClientDefaultHeader[struct{}, struct{}]("X-Replaced", "default", true),
+		httptransport.ClientDefaultHeader[struct{}, struct{}]("X-Before", "default", true),
+		httptransport.ClientBefore[struct{}, struct{}](httptransport.SetRequestHeader("X-Before", "before")),
+	)
+
+	if _, err := client.Endpoint()(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"User-Agent": "gkit-test/1.0",
+		"X-Kept":     "encoder",
+		"X-Replaced": "default",
+		"X-Before":   "before",
+	} {
+		if have := have.Get(key); want != have {
+			t.Errorf("%s: want %q, have %q", key, want, have)
+		}
+	}
+}
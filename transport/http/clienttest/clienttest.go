@@ -0,0 +1,241 @@
+// Package clienttest provides a test double HTTP server checking the requests
+// of a client against programmed expectations, for concise client contract
+// tests.
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Server is an httptest.Server answering the requests matching its
+// expectations with their canned responses. Requests matching no expectation
+// fail the test and are answered with 501 Not Implemented. Once the test ends,
+// the server is closed and the test fails if an expectation wasn't met.
+type Server struct {
+	*httptest.Server
+
+	t            testing.TB
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// NewServer starts a Server without expectations, closed and checked with
+// AssertExpectations when t ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(func() {
+		s.Close()
+		s.AssertExpectations()
+	})
+	return s
+}
+
+// Expect adds an expectation for a request of method to path, which by
+// default must be made exactly once and is answered with an empty 200 OK.
+// Expectations are matched in the order they were added. Configure them
+// before the client calls the server.
+func (s *Server) Expect(method, path string) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &Expectation{
+		t:      s.t,
+		method: method,
+		path:   path,
+		header: make(http.Header),
+		times:  1,
+		status: http.StatusOK,
+		resp:   make(http.Header),
+	}
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// AssertExpectations fails the test for every expectation that wasn't matched
+// as many times as expected. It's called when the test ends.
+func (s *Server) AssertExpectations() {
+	s.t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.expectations {
+		if e.calls != e.times {
+			s.t.Errorf("clienttest: %s: want %d calls, have %d", e, e.times, e.calls)
+		}
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.t.Errorf("clienttest: reading the body of %s %s: %v", r.Method, r.URL, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	var (
+		matched    *Expectation
+		mismatches []string
+	)
+	for _, e := range s.expectations {
+		if e.calls >= e.times || e.method != r.Method || e.path != r.URL.Path {
+			continue
+		}
+		if err := e.match(r, body); err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %v", e, err))
+			continue
+		}
+		matched = e
+		matched.calls++
+		break
+	}
+	s.mu.Unlock()
+
+	if matched == nil {
+		s.t.Errorf("clienttest: unexpected request %s %s %v", r.Method, r.URL, mismatches)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	for k, values := range matched.resp {
+		w.Header()[k] = values
+	}
+	w.WriteHeader(matched.status)
+	w.Write(matched.body) //nolint:errcheck
+}
+
+// Expectation describes an expected request and the response it gets. Its
+// methods return the Expectation, so they can be chained.
+type Expectation struct {
+	t            testing.TB
+	method, path string
+	query        map[string]string
+	header       http.Header
+	matchBody    func(body []byte) error
+	times        int
+	calls        int
+
+	status int
+	resp   http.Header
+	body   []byte
+}
+
+func (e *Expectation) String() string {
+	return e.method + " " + e.path
+}
+
+// WithHeader requires the request to have a key header equal to value.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.header.Set(key, value)
+	return e
+}
+
+// WithQuery requires the request to have a key query parameter equal to
+// value.
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	if e.query == nil {
+		e.query = make(map[string]string)
+	}
+	e.query[key] = value
+	return e
+}
+
+// WithBody requires match to return nil for the body of the request. The
+// error it returns otherwise is reported when no expectation matches.
+func (e *Expectation) WithBody(match func(body []byte) error) *Expectation {
+	e.matchBody = match
+	return e
+}
+
+// WithJSONBody requires the body of the request to be the JSON encoding of
+// v, regardless of formatting and field order.
+func (e *Expectation) WithJSONBody(v any) *Expectation {
+	want, err := normalizeJSON(v)
+	return e.WithBody(func(body []byte) error {
+		if err != nil {
+			return err
+		}
+		var have any
+		if err := json.Unmarshal(body, &have); err != nil {
+			return fmt.Errorf("body isn't JSON: %w", err)
+		}
+		if !reflect.DeepEqual(want, have) {
+			b, _ := json.Marshal(want)
+			return fmt.Errorf("want body %s, have %s", b, bytes.TrimSpace(body))
+		}
+		return nil
+	})
+}
+
+// Times sets how many times the request must be made.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Respond sets the status code and body of the response.
+func (e *Expectation) Respond(status int, body []byte) *Expectation {
+	e.status, e.body = status, body
+	e.resp.Set("Content-Length", strconv.Itoa(len(body)))
+	return e
+}
+
+// RespondJSON sets the status code of the response, and its body to the JSON
+// encoding of v. It fails the test if v can't be encoded.
+func (e *Expectation) RespondJSON(status int, v any) *Expectation {
+	e.t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		e.t.Fatalf("clienttest: %s: encoding the response: %v", e, err)
+	}
+	e.resp.Set("Content-Type", "application/json; charset=utf-8")
+	return e.Respond(status, b)
+}
+
+// RespondHeader sets a key header on the response.
+func (e *Expectation) RespondHeader(key, value string) *Expectation {
+	e.resp.Set(key, value)
+	return e
+}
+
+func (e *Expectation) match(r *http.Request, body []byte) error {
+	for k := range e.header {
+		if want, have := e.header.Get(k), r.Header.Get(k); want != have {
+			return fmt.Errorf("header %s: want %q, have %q", k, want, have)
+		}
+	}
+	for k, want := range e.query {
+		if have := r.URL.Query().Get(k); want != have {
+			return fmt.Errorf("query parameter %s: want %q, have %q", k, want, have)
+		}
+	}
+	if e.matchBody != nil {
+		return e.matchBody(body)
+	}
+	return nil
+}
+
+// normalizeJSON returns v as decoded from its JSON encoding, to compare it to
+// a decoded body.
+func normalizeJSON(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	err = json.Unmarshal(b, &normalized)
+	return normalized, err
+}
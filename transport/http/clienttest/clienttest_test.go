@@ -0,0 +1,105 @@
+//go:build unit
+
+package clienttest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+	"github.com/bobobox-id/gkit/transport/http/clienttest"
+)
+
+type createUser struct {
+	Name string `json:"name"`
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newClient(t *testing.T, server *clienttest.Server) *httptransport.Client[createUser, user] {
+	target, err := url.Parse(server.URL + "/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptransport.NewClient(
+		http.MethodPost,
+		target,
+		httptransport.EncodeJSONRequest[createUser],
+		httptransport.DecodeJSONResponse[user],
+		httptransport.ClientBefore[createUser, user](httptransport.SetRequestBearerToken("token")),
+	)
+}
+
+func TestServer(t *testing.T) {
+	server := clienttest.NewServer(t)
+	server.Expect(http.MethodPost, "/users").
+		WithHeader("Authorization", "Bearer token").
+		WithJSONBody(createUser{Name: "ada"}).
+		RespondJSON(http.StatusCreated, user{ID: 1, Name: "ada"}).
+		Times(2)
+
+	for i := 0; i < 2; i++ {
+		u, err := newClient(t, server).Endpoint()(context.Background(), createUser{Name: "ada"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := (user{ID: 1, Name: "ada"}), u; want != have {
+			t.Errorf("want %+v, have %+v", want, have)
+		}
+	}
+}
+
+// recordingT records the failures of a test instead of failing it.
+type recordingT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Fatalf(format string, args ...any) { t.Errorf(format, args...) }
+
+func (t *recordingT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func TestServerFailures(t *testing.T) {
+	rt := &recordingT{TB: t}
+	server := clienttest.NewServer(rt)
+	server.Expect(http.MethodPost, "/users").WithJSONBody(createUser{Name: "grace"})
+	server.Expect(http.MethodGet, "/health")
+
+	if _, err := newClient(t, server).Endpoint()(context.Background(), createUser{Name: "ada"}); err == nil {
+		t.Error("want an error for an unexpected request, have none")
+	}
+	for _, f := range rt.cleanups {
+		f()
+	}
+
+	// The mismatched request, then the two unmet expectations.
+	if want, have := 3, len(rt.errors); want != have {
+		t.Fatalf("want %d failures, have %d: %q", want, have, rt.errors)
+	}
+}
+
+func TestServerRespondJSONError(t *testing.T) {
+	rt := &recordingT{TB: t}
+	server := clienttest.NewServer(rt)
+	server.Expect(http.MethodGet, "/health").Times(0).RespondJSON(http.StatusOK, make(chan int))
+	for _, f := range rt.cleanups {
+		f()
+	}
+
+	if want, have := 1, len(rt.errors); want != have {
+		t.Fatalf("want %d failures, have %d: %q", want, have, rt.errors)
+	}
+}
@@ -0,0 +1,92 @@
+package compress
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// AcceptEncoding is the value set by SetAcceptEncoding, listing every encoding
+// DecompressResponse can decode.
+const AcceptEncoding = "br, zstd, gzip"
+
+// SetAcceptEncoding is a RequestFunc, to be used with ClientBefore, that
+// advertises the encodings supported by DecompressResponse. Note that setting
+// Accept-Encoding disables the transparent gzip handling of http.Transport.
+func SetAcceptEncoding(ctx context.Context, r *http.Request) context.Context {
+	r.Header.Set("Accept-Encoding", AcceptEncoding)
+	return ctx
+}
+
+// DecompressResponse is a ClientResponseFunc, to be used with ClientAfter,
+// that transparently decompresses the response body according to its
+// Content-Encoding header, which may be br, zstd or gzip. On success the
+// Content-Encoding header is removed and ContentLength is set to -1, as the
+// decompressed length is unknown. Invalid compressed data surfaces as an error
+// when the body is read, i.e. in the decode func. Unknown encodings are left
+// untouched.
+func DecompressResponse(ctx context.Context, resp *http.Response) context.Context {
+	var (
+		body io.ReadCloser
+		err  error
+	)
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "br":
+		body = &decompressedBody{Reader: brotli.NewReader(resp.Body), body: resp.Body}
+	case "zstd":
+		var dec *zstd.Decoder
+		if dec, err = zstd.NewReader(resp.Body); err == nil {
+			body = &decompressedBody{Reader: dec, body: resp.Body, close: dec.Close}
+		}
+	case "gzip":
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(resp.Body); err == nil {
+			body = &decompressedBody{Reader: gz, body: resp.Body}
+		}
+	default:
+		return ctx
+	}
+
+	if err != nil {
+		body = &decompressedBody{Reader: errReader{err}, body: resp.Body}
+	}
+
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return ctx
+}
+
+// decompressedBody reads from the decompressor and closes the original body.
+type decompressedBody struct {
+	io.Reader
+
+	body  io.ReadCloser
+	close func()
+}
+
+func (b *decompressedBody) Close() error {
+	if b.close != nil {
+		b.close()
+	}
+	return b.body.Close()
+}
+
+// Unwrap returns the compressed body, so the Client tells the body of a
+// BufferedStream apart from others.
+func (b *decompressedBody) Unwrap() io.ReadCloser {
+	return b.body
+}
+
+// errReader fails every read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
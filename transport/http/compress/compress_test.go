@@ -0,0 +1,74 @@
+//go:build unit
+
+package compress_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/bobobox-id/gkit/transport/http/compress"
+)
+
+func TestDecompressResponse(t *testing.T) {
+	const payload = "hello, compressed world"
+
+	encoders := map[string]func(w io.Writer) io.WriteCloser{
+		"br": func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"zstd": func(w io.Writer) io.WriteCloser {
+			enc, _ := zstd.NewWriter(w)
+			return enc
+		},
+		"gzip": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+	}
+
+	for encoding, newWriter := range encoders {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		io.WriteString(w, payload)
+		w.Close()
+
+		resp := &http.Response{
+			Header:        http.Header{"Content-Encoding": []string{encoding}},
+			Body:          io.NopCloser(&buf),
+			ContentLength: int64(buf.Len()),
+		}
+		compress.DecompressResponse(context.Background(), resp)
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		if want, have := payload, string(b); want != have {
+			t.Errorf("%s: want %q, have %q", encoding, want, have)
+		}
+		if have := resp.Header.Get("Content-Encoding"); have != "" {
+			t.Errorf("%s: want Content-Encoding removed, have %q", encoding, have)
+		}
+		if want, have := int64(-1), resp.ContentLength; want != have {
+			t.Errorf("%s: ContentLength: want %d, have %d", encoding, want, have)
+		}
+		if _, ok := resp.Body.(interface{ Unwrap() io.ReadCloser }); !ok {
+			t.Errorf("%s: want the body to unwrap to the compressed body", encoding)
+		}
+	}
+}
+
+func TestDecompressResponseCorrupt(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:   io.NopCloser(strings.NewReader("not gzip")),
+	}
+	compress.DecompressResponse(context.Background(), resp)
+
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Error("want error, have none")
+	}
+}
@@ -0,0 +1,5 @@
+// Package compress provides response decompression for the HTTP transport
+// beyond what the standard library supports, namely brotli and zstd. It lives
+// in its own module so that the compression dependencies are only pulled in by
+// users who need them.
+package compress
@@ -0,0 +1,8 @@
+module github.com/bobobox-id/gkit/transport/http/compress
+
+go 1.21.6
+
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/klauspost/compress v1.17.5
+)
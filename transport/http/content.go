@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Content is a response streamed from a reader rather than marshaled, e.g. a
+// file or the body of a proxied response. Endpoints return it with its
+// metadata, and EncodeContentResponse writes it to the client.
+type Content struct {
+	// Body is copied to the client and always closed by
+	// EncodeContentResponse. A nil Body is an empty response.
+	Body io.ReadCloser
+
+	// Type is the Content-Type of the response, defaulting to
+	// application/octet-stream.
+	Type string
+
+	// Length is the Content-Length of the response, or zero if unknown. It's
+	// ignored if Body implements io.Seeker, as the length is found by seeking.
+	Length int64
+
+	// Filename, if set, is sent in the Content-Disposition header, asking the
+	// client to save the response under that name unless Inline is set. See
+	// FormatContentDisposition.
+	Filename string
+	Inline   bool
+
+	// ModTime, if set, is sent in the Last-Modified header.
+	ModTime time.Time
+}
+
+// Disposition implements Dispositioner.
+func (c Content) Disposition() (filename string, inline bool) {
+	return c.Filename, c.Inline
+}
+
+// ContentFromResponse returns the Content of a client response, so an
+// endpoint can pass a backend body through to its own client without
+// buffering it. The response must be decoded by a Client with BufferedStream
+// enabled, or the body is closed before it's read.
+func ContentFromResponse(resp *http.Response) Content {
+	c := Content{
+		Body:   resp.Body,
+		Type:   resp.Header.Get("Content-Type"),
+		Length: max(resp.ContentLength, 0),
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		c.ModTime = modTime
+	}
+	return c
+}
+
+// EncodeContentResponse is an EncodeResponseFunc that streams the Body of the
+// Content to the client, closing it afterwards.
+//
+// If Body implements io.ReadSeeker, e.g. an *os.File, it's served with
+// http.ServeContent, which answers the Range header of the request with a
+// partial response, and to its conditional headers (If-None-Match,
+// If-Modified-Since, If-Match, If-Unmodified-Since and If-Range) with 304 Not
+// Modified or 412 Precondition Failed. These headers and the request method
+// are read from the context, so PopulateRequestContext must be among the
+// server before funcs for range and conditional requests to be served.
+func EncodeContentResponse(ctx context.Context, w http.ResponseWriter, c Content) error {
+	if c.Body == nil {
+		c.Body = http.NoBody
+	}
+	defer c.Body.Close()
+
+	if c.Type == "" {
+		c.Type = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", c.Type)
+	setDisposition(w, c)
+
+	method, _ := ctx.Value(ContextKeyRequestMethod).(string)
+	if seeker, ok := c.Body.(io.ReadSeeker); ok {
+		r := &http.Request{Method: method, Header: http.Header{}}
+		if r.Method == "" {
+			r.Method = http.MethodGet
+		}
+		for key, name := range map[contextKey]string{
+			ContextKeyRequestRange:             "Range",
+			ContextKeyRequestIfRange:           "If-Range",
+			ContextKeyRequestIfNoneMatch:       "If-None-Match",
+			ContextKeyRequestIfModifiedSince:   "If-Modified-Since",
+			ContextKeyRequestIfMatch:           "If-Match",
+			ContextKeyRequestIfUnmodifiedSince: "If-Unmodified-Since",
+		} {
+			if v, _ := ctx.Value(key).(string); v != "" {
+				r.Header.Set(name, v)
+			}
+		}
+		http.ServeContent(w, r, "", c.ModTime, seeker)
+		return nil
+	}
+
+	if !c.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", c.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if c.Length > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(c.Length, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if method == http.MethodHead {
+		return nil
+	}
+
+	_, err := io.Copy(w, c.Body)
+	return err
+}
@@ -0,0 +1,166 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+type seekCloseRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (c *seekCloseRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestEncodeContentResponse(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader("hello, world")}
+	content := httptransport.Content{Body: body, Type: "text/plain", Length: 12, Filename: "hello.txt"}
+
+	rec := httptest.NewRecorder()
+	if err := httptransport.EncodeContentResponse(context.Background(), rec, content); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := "hello, world", rec.Body.String(); want != have {
+		t.Errorf("body: want %q, have %q", want, have)
+	}
+	for key, want := range map[string]string{
+		"Content-Type":        "text/plain",
+		"Content-Length":      "12",
+		"Content-Disposition": `attachment; filename="hello.txt"`,
+	} {
+		if have := rec.Header().Get(key); want != have {
+			t.Errorf("%s: want %q, have %q", key, want, have)
+		}
+	}
+	if !body.closed {
+		t.Error("body not closed")
+	}
+}
+
+func TestEncodeContentResponseRange(t *testing.T) {
+	var body *seekCloseRecorder
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (httptransport.Content, error) {
+			body = &seekCloseRecorder{Reader: strings.NewReader("hello, world")}
+			return httptransport.Content{Body: body, Type: "text/plain"}, nil
+		},
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		httptransport.EncodeContentResponse,
+		httptransport.ServerBefore[struct{}, httptransport.Content](httptransport.PopulateRequestContext),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=7-")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if want, have := http.StatusPartialContent, rec.Code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "world", rec.Body.String(); want != have {
+		t.Errorf("body: want %q, have %q", want, have)
+	}
+	if want, have := "bytes 7-11/12", rec.Header().Get("Content-Range"); want != have {
+		t.Errorf("Content-Range: want %q, have %q", want, have)
+	}
+	if !body.closed {
+		t.Error("body not closed")
+	}
+}
+
+func TestEncodeContentResponseConditional(t *testing.T) {
+	modTime := time.Date(2026, time.January, 2, 15, 4, 5, 0, time.UTC)
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (httptransport.Content, error) {
+			body := &seekCloseRecorder{Reader: strings.NewReader("hello, world")}
+			return httptransport.Content{Body: body, Type: "text/plain", ModTime: modTime}, nil
+		},
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		httptransport.EncodeContentResponse,
+		httptransport.ServerBefore[struct{}, httptransport.Content](httptransport.PopulateRequestContext),
+	)
+
+	for _, tc := range []struct {
+		header, value string
+		code          int
+	}{
+		{"If-Modified-Since", modTime.Format(http.TimeFormat), http.StatusNotModified},
+		{"If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK},
+		{"If-Unmodified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat), http.StatusPreconditionFailed},
+		{"If-Match", `"v1"`, http.StatusPreconditionFailed},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(tc.header, tc.value)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if want, have := tc.code, rec.Code; want != have {
+			t.Errorf("%s: %s: want %d, have %d", tc.header, tc.value, want, have)
+		}
+	}
+}
+
+func TestContentFromResponse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		w.Write([]byte("png bytes"))
+	}))
+	defer backend.Close()
+
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse(backend.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, resp *http.Response) (httptransport.Content, error) {
+			return httptransport.ContentFromResponse(resp), nil
+		},
+		httptransport.BufferedStream[struct{}, httptransport.Content](true),
+	)
+
+	content, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := httptransport.EncodeContentResponse(context.Background(), rec, content); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"Content-Type":   "image/png",
+		"Content-Length": "9",
+		"Last-Modified":  "Wed, 21 Oct 2015 07:28:00 GMT",
+	} {
+		if have := rec.Header().Get(key); want != have {
+			t.Errorf("%s: want %q, have %q", key, want, have)
+		}
+	}
+	if want, have := "png bytes", rec.Body.String(); want != have {
+		t.Errorf("body: want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS handling of a Server, see ServerCORS.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the server, e.g.
+	// "https://app.example.com", or "*" to allow any origin.
+	AllowedOrigins []string
+
+	// AllowedMethods lists the methods allowed on preflight requests. It
+	// defaults to GET, HEAD and POST.
+	AllowedMethods []string
+
+	// AllowedHeaders lists the request headers allowed on preflight requests,
+	// on top of the CORS-safelisted ones browsers always allow.
+	AllowedHeaders []string
+
+	// MaxAge is how long browsers may cache the result of a preflight
+	// request. It's not sent when zero.
+	MaxAge time.Duration
+}
+
+// ServerCORS makes the server handle CORS for browser clients. A preflight
+// request, an OPTIONS request carrying Origin and
+// Access-Control-Request-Method headers, is answered with 204 No Content and
+// the Access-Control-Allow-* headers, without invoking the endpoint or any
+// before func; when its origin or method isn't allowed, the headers are left
+// out, so the browser blocks the actual request. On other requests from an
+// allowed origin, the Access-Control-Allow-Origin header is added to the
+// response. Preflight requests only reach the server when the router sends
+// OPTIONS requests to it.
+func ServerCORS[Req, Res any](cfg CORSConfig) ServerOption[Req, Res] {
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	return func(s *Server[Req, Res]) { s.cors = &cfg }
+}
+
+// handle sets the CORS headers of the response to r, and reports whether r
+// was a preflight request, answered already.
+func (cfg *CORSConfig) handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	if preflight {
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	allowOrigin, ok := cfg.allowOrigin(origin)
+	if !preflight {
+		if ok {
+			h.Set("Access-Control-Allow-Origin", allowOrigin)
+		}
+		return false
+	}
+
+	if ok && containsFold(cfg.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+		h.Set("Access-Control-Allow-Origin", allowOrigin)
+		h.Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+		if len(cfg.AllowedHeaders) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+		}
+		if cfg.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge/time.Second)))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, if
+// it's allowed.
+func (cfg *CORSConfig) allowOrigin(origin string) (string, bool) {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" {
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestServerCORS(t *testing.T) {
+	var called int
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (struct{}, error) { called++; return struct{}{}, nil },
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		httptransport.EncodeJSONResponse[struct{}],
+		httptransport.ServerCORS[struct{}, struct{}](httptransport.CORSConfig{
+			AllowedOrigins: []string{"https://app.example.com"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPut},
+			AllowedHeaders: []string{"Authorization", "Content-Type"},
+			MaxAge:         10 * time.Minute,
+		}),
+	)
+
+	preflight := func(origin, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodOptions, "/", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := preflight("https://app.example.com", http.MethodPut)
+	if want, have := http.StatusNoContent, rec.Code; want != have {
+		t.Errorf("preflight: StatusCode: want %d, have %d", want, have)
+	}
+	for k, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, PUT",
+		"Access-Control-Allow-Headers": "Authorization, Content-Type",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if have := rec.Header().Get(k); want != have {
+			t.Errorf("preflight: %s: want %q, have %q", k, want, have)
+		}
+	}
+	if called != 0 {
+		t.Errorf("preflight: want the endpoint not invoked, have %d calls", called)
+	}
+
+	for _, rec := range []*httptest.ResponseRecorder{
+		preflight("https://evil.example.com", http.MethodPut),
+		preflight("https://app.example.com", http.MethodDelete),
+	} {
+		if have := rec.Header().Get("Access-Control-Allow-Origin"); have != "" {
+			t.Errorf("disallowed preflight: want no Access-Control-Allow-Origin, have %q", have)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if want, have := "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"); want != have {
+		t.Errorf("simple: want %q, have %q", want, have)
+	}
+	if want, have := 1, called; want != have {
+		t.Errorf("simple: want %d call, have %d", want, have)
+	}
+}
+
+func TestServerCORSWildcard(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (struct{}, error) { return struct{}{}, nil },
+		func(context.Context, *http.Request) (struct{}, error) { return struct{}{}, nil },
+		httptransport.EncodeJSONResponse[struct{}],
+		httptransport.ServerCORS[struct{}, struct{}](httptransport.CORSConfig{AllowedOrigins: []string{"*"}}),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Origin", "https://any.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if want, have := "*", rec.Header().Get("Access-Control-Allow-Origin"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
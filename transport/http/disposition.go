@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Dispositioner is checked by EncodeDisposition and EncodeContentResponse. If
+// a response implements Dispositioner, the Content-Disposition header is set
+// from the returned filename, asking the client to save the response under
+// that name unless inline is true. An empty filename sets no header.
+type Dispositioner interface {
+	Disposition() (filename string, inline bool)
+}
+
+// EncodeDisposition wraps enc, setting the Content-Disposition header of
+// Dispositioner responses before enc writes them, e.g. for download endpoints
+// returning JSON or CSV exports.
+func EncodeDisposition[Res any](enc EncodeResponseFunc[Res]) EncodeResponseFunc[Res] {
+	return func(ctx context.Context, w http.ResponseWriter, response Res) error {
+		setDisposition(w, response)
+		return enc(ctx, w, response)
+	}
+}
+
+// FormatContentDisposition returns a Content-Disposition header value for the
+// given disposition type, e.g. "attachment", and filename. Quotes and
+// backslashes in the filename are escaped. Non-ASCII filenames are also sent
+// percent-encoded in the filename* parameter of RFC 5987, with an ASCII
+// fallback in filename for older clients.
+func FormatContentDisposition(disposition, filename string) string {
+	var (
+		fallback strings.Builder
+		ascii    = true
+	)
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		case r < ' ' || r == 0x7f:
+			fallback.WriteByte('_')
+		case r > 0x7f:
+			fallback.WriteByte('_')
+			ascii = false
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	value := disposition + `; filename="` + fallback.String() + `"`
+	if !ascii {
+		value += "; filename*=UTF-8''" + encodeExtValue(filename)
+	}
+	return value
+}
+
+// encodeExtValue percent-encodes s, keeping the attr-char set of RFC 5987.
+func encodeExtValue(s string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
+
+// setDisposition sets the Content-Disposition header of a Dispositioner
+// response.
+func setDisposition(w http.ResponseWriter, response any) {
+	dispositioner, ok := response.(Dispositioner)
+	if !ok {
+		return
+	}
+
+	filename, inline := dispositioner.Disposition()
+	if filename == "" {
+		return
+	}
+
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", FormatContentDisposition(disposition, filename))
+}
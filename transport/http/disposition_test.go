@@ -0,0 +1,47 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestFormatContentDisposition(t *testing.T) {
+	for filename, want := range map[string]string{
+		"report.pdf":      `attachment; filename="report.pdf"`,
+		`say "hi".txt`:    `attachment; filename="say \"hi\".txt"`,
+		"résumé 1.pdf":    `attachment; filename="r_sum_ 1.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9%201.pdf`,
+		"日本.csv":          `attachment; filename="__.csv"; filename*=UTF-8''%E6%97%A5%E6%9C%AC.csv`,
+		"line\nbreak.txt": `attachment; filename="line_break.txt"`,
+	} {
+		if have := httptransport.FormatContentDisposition("attachment", filename); want != have {
+			t.Errorf("%q: want %s, have %s", filename, want, have)
+		}
+	}
+}
+
+type download struct {
+	Rows []string `json:"rows"`
+}
+
+func (download) Disposition() (string, bool) { return "export.json", false }
+
+func TestEncodeDisposition(t *testing.T) {
+	rec := httptest.NewRecorder()
+	enc := httptransport.EncodeDisposition(httptransport.EncodeJSONResponse[download])
+	if err := enc(context.Background(), rec, download{Rows: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := `attachment; filename="export.json"`, rec.Header().Get("Content-Disposition"); want != have {
+		t.Errorf("Content-Disposition: want %s, have %s", want, have)
+	}
+	if want, have := http.StatusOK, rec.Code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// Envelope names the fields of the standard response envelope written by
+// EnvelopeResponse and EnvelopeError:
+//
+//	{"data": ..., "meta": ..., "request_id": ...}
+//	{"error": ..., "request_id": ...}
+//
+// An empty name selects the default shown above. The meta field is only
+// written for responses implementing Metaer, and the request ID only when
+// one is found with gkit.RequestIDFromContext.
+type Envelope struct {
+	Data      string
+	Meta      string
+	RequestID string
+	Error     string
+}
+
+// Metaer is checked by EnvelopeResponse. If a response implements Metaer, the
+// value returned by Meta, e.g. pagination details, is written in the meta
+// field of the envelope.
+type Metaer interface {
+	Meta() any
+}
+
+func (e Envelope) field(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// EnvelopeResponse returns an EncodeResponseFunc writing the response in the
+// data field of env, so all endpoints of an API share one response shape.
+// Like EncodeJSONResponse, it applies the headers of a Headerer response and
+// the status code of a StatusCoder response, and sets Content-Length. An
+// endpoint opts out of the envelope by using EncodeJSONResponse instead.
+func EnvelopeResponse[Res any](env Envelope) EncodeResponseFunc[Res] {
+	return func(ctx context.Context, w http.ResponseWriter, response Res) error {
+		body := map[string]any{env.field(env.Data, "data"): response}
+		if metaer, ok := any(response).(Metaer); ok {
+			body[env.field(env.Meta, "meta")] = metaer.Meta()
+		}
+		if id := gkit.RequestIDFromContext(ctx); id != "" {
+			body[env.field(env.RequestID, "request_id")] = id
+		}
+
+		return writeEnvelope(w, response, statusCode(response), body)
+	}
+}
+
+// EnvelopeError returns an error encoder, for ServerErrorEncoder, writing the
+// error in the error field of env: its JSON form if it implements
+// json.Marshaler, its message otherwise. Like DefaultErrorEncoder, it applies
+// the headers of a Headerer error and the status code of a StatusCoder error,
+// defaulting to 500, found in the chain of the error with errors.As.
+func EnvelopeError(env Envelope) gkit.ErrorEncoder[http.ResponseWriter] {
+	return func(ctx context.Context, w http.ResponseWriter, err error) {
+		var value any = err.Error()
+		if marshaler, ok := asError[json.Marshaler](err); ok {
+			if b, marshalErr := marshaler.MarshalJSON(); marshalErr == nil {
+				value = json.RawMessage(b)
+			}
+		}
+
+		body := map[string]any{env.field(env.Error, "error"): value}
+		if id := gkit.RequestIDFromContext(ctx); id != "" {
+			body[env.field(env.RequestID, "request_id")] = id
+		}
+
+		var headerer any
+		if h, ok := asError[Headerer](err); ok {
+			headerer = h
+		}
+
+		writeEnvelope(w, headerer, errorStatusCode(err), body) //nolint:errcheck
+	}
+}
+
+// writeEnvelope writes body as JSON with the given status code, and the
+// headers of v if it implements Headerer.
+func writeEnvelope(w http.ResponseWriter, v any, code int, body map[string]any) error {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(body); err != nil {
+		return err
+	}
+
+	setJSONHeaders(w, v)
+	w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
+	w.WriteHeader(code)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,75 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type pagedUsers struct {
+	Users []string
+	Total int
+}
+
+func (p pagedUsers) MarshalJSON() ([]byte, error) {
+	return []byte(`["` + strings.Join(p.Users, `","`) + `"]`), nil
+}
+
+func (p pagedUsers) Meta() any { return map[string]int{"total": p.Total} }
+
+func TestEnvelopeResponse(t *testing.T) {
+	ctx := gkit.WithRequestID(context.Background(), "req-1")
+
+	for _, tc := range []struct {
+		env  httptransport.Envelope
+		want string
+	}{
+		{httptransport.Envelope{}, `{"data":["ann","bob"],"meta":{"total":2},"request_id":"req-1"}`},
+		{httptransport.Envelope{Data: "result", RequestID: "trace"}, `{"meta":{"total":2},"result":["ann","bob"],"trace":"req-1"}`},
+	} {
+		w := httptest.NewRecorder()
+		err := httptransport.EnvelopeResponse[pagedUsers](tc.env)(ctx, w, pagedUsers{Users: []string{"ann", "bob"}, Total: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := strings.TrimSpace(w.Body.String()); tc.want != have {
+			t.Errorf("want %s, have %s", tc.want, have)
+		}
+	}
+}
+
+func TestEnvelopeError(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (struct{}, error) { return struct{}{}, errors.New("boom") },
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		httptransport.EnvelopeResponse[struct{}](httptransport.Envelope{}),
+		httptransport.ServerErrorEncoder[struct{}, struct{}](httptransport.EnvelopeError(httptransport.Envelope{})),
+		httptransport.ServerErrorHandler[struct{}, struct{}](gkit.ErrorHandlerFunc(func(context.Context, error) {})),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want, have := http.StatusInternalServerError, w.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := `{"error":"boom"}`, strings.TrimSpace(w.Body.String()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+
+	w = httptest.NewRecorder()
+	httptransport.EnvelopeError(httptransport.Envelope{})(context.Background(), w, enhancedError{})
+	if want, have := http.StatusTeapot, w.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if want, have := `{"error":{"err":"enhanced"}}`, strings.TrimSpace(w.Body.String()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
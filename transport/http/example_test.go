@@ -4,10 +4,15 @@ package http_test
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 
+	gkit "github.com/bobobox-id/gkit/core"
 	httptransport "github.com/bobobox-id/gkit/transport/http"
 )
 
@@ -38,3 +43,72 @@ func ExamplePopulateRequestContext() {
 	// RequestURI /search?q=sympatico
 	// X-Request-ID a1b2c3d4e5
 }
+
+// A gateway accepting JSON and calling an XML backend. The front server and
+// the backend client each only deal with their own format, and
+// gkit.Translate maps the request and response shapes between them.
+func Example_gateway() {
+	type (
+		quoteRequest struct {
+			Symbol string `json:"symbol"`
+		}
+		quoteResponse struct {
+			Symbol string  `json:"symbol"`
+			Price  float64 `json:"price"`
+		}
+		backendRequest struct {
+			XMLName xml.Name `xml:"GetQuote"`
+			Ticker  string   `xml:"Ticker"`
+		}
+		backendResponse struct {
+			XMLName xml.Name `xml:"Quote"`
+			Ticker  string   `xml:"Ticker"`
+			Last    float64  `xml:"Last"`
+		}
+	)
+
+	// The XML backend, e.g. a legacy service.
+	backend := httptest.NewServer(httptransport.NewServer(
+		func(_ context.Context, req backendRequest) (backendResponse, error) {
+			return backendResponse{Ticker: req.Ticker, Last: 123.45}, nil
+		},
+		httptransport.DecodeXMLRequest[backendRequest],
+		httptransport.EncodeXMLResponse[backendResponse],
+	))
+	defer backend.Close()
+
+	tgt, _ := url.Parse(backend.URL)
+	client := httptransport.NewClient[backendRequest, backendResponse](
+		http.MethodPost, tgt,
+		httptransport.EncodeXMLRequest[backendRequest],
+		httptransport.DecodeXMLResponse[backendResponse],
+	)
+
+	// The JSON front, translating to and from the backend shapes.
+	gateway := httptest.NewServer(httptransport.NewServer(
+		gkit.Translate[quoteRequest, quoteResponse](
+			client.Endpoint(),
+			func(_ context.Context, req quoteRequest) (backendRequest, error) {
+				return backendRequest{Ticker: strings.ToUpper(req.Symbol)}, nil
+			},
+			func(_ context.Context, res backendResponse) (quoteResponse, error) {
+				return quoteResponse{Symbol: res.Ticker, Price: res.Last}, nil
+			},
+		),
+		httptransport.DecodeJSONRequest[quoteRequest],
+		httptransport.EncodeJSONResponse[quoteResponse],
+	))
+	defer gateway.Close()
+
+	resp, err := http.Post(gateway.URL, "application/json", strings.NewReader(`{"symbol":"acme"}`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Print(string(body))
+
+	// Output:
+	// {"symbol":"ACME","price":123.45}
+}
@@ -2,4 +2,9 @@ module github.com/bobobox-id/gkit/transport/http
 
 go 1.21.6
 
-require github.com/bobobox-id/gkit/core v0.1.0
+require (
+	github.com/bobobox-id/gkit/core v0.2.0
+	golang.org/x/net v0.20.0
+)
+
+require golang.org/x/text v0.14.0 // indirect
@@ -0,0 +1,65 @@
+//go:build unit
+
+package http_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestEncodeGzipJSONRequest(t *testing.T) {
+	payload := map[string]string{"data": strings.Repeat("gkit ", 1000)}
+	want, _ := json.Marshal(payload)
+
+	var have []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if enc := r.Header.Get("Content-Encoding"); enc != "gzip" {
+			t.Errorf("Content-Encoding: want gzip, have %q", enc)
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		have, _ = io.ReadAll(zr)
+	}))
+	defer server.Close()
+
+	// Encode twice to go through a pooled writer.
+	for i := 0; i < 2; i++ {
+		client := httptransport.NewClient(
+			http.MethodPost,
+			mustParse(server.URL),
+			httptransport.EncodeGzipJSONRequest[map[string]string],
+			func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		)
+		if _, err := client.Endpoint()(context.Background(), payload); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(want, bytes.TrimSpace(have)) {
+			t.Errorf("round trip %d: body differs, have %d bytes, want %d", i, len(have), len(want))
+		}
+	}
+}
+
+func TestEncodeGzipJSONRequestContentLength(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if err := httptransport.EncodeGzipJSONRequest(context.Background(), r, "payload"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := io.ReadAll(r.Body)
+	if want, have := int64(len(b)), r.ContentLength; want != have {
+		t.Errorf("ContentLength: want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// DefaultMaxErrorBodySize is the maximum number of bytes of an error response
+// body captured by DecodeHTTPError when no explicit limit is given.
+const DefaultMaxErrorBodySize = 4 << 10
+
+// HTTPError is returned by DecodeHTTPError when the remote server responds
+// with a non-2xx status code. It carries the status code, headers and up to
+// the configured number of bytes of the response body.
+type HTTPError struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+
+	// Truncated reports whether Body was cut short because the response body
+	// was larger than the configured limit.
+	Truncated bool
+
+	// Request is the request that failed, captured when its context was
+	// marked with gkit.WithDebug, and nil otherwise. Pass it to ReplayRequest
+	// to reproduce the failure.
+	Request *CapturedRequest
+}
+
+// CapturedRequest is the detail of a failed request kept by DecodeHTTPError
+// for debugging. Its body is bounded like the body of the HTTPError, and the
+// values of secret headers and query parameters are replaced by Redacted, so
+// it's safe to log.
+type CapturedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+
+	// Truncated reports whether Body was cut short, in which case the request
+	// can't be replayed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Redacted replaces the secret values of a CapturedRequest.
+const Redacted = "[REDACTED]"
+
+// RedactedHeaders and RedactedQueryParams list the headers and query
+// parameters whose values are replaced by Redacted in a CapturedRequest.
+// Names are case-insensitive.
+var (
+	RedactedHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+	RedactedQueryParams = []string{"access_token", "api_key", "key", "password", "token"}
+)
+
+// ErrTruncatedRequest is returned by ReplayRequest for a CapturedRequest
+// whose body was truncated.
+var ErrTruncatedRequest = errors.New("http: captured request body truncated")
+
+// Error implements the error interface. Truncated bodies are marked with a
+// trailing ellipsis.
+func (e *HTTPError) Error() string {
+	body := string(e.Body)
+	if e.Truncated {
+		body += "..."
+	}
+	return fmt.Sprintf("http: unexpected status %d %s: %s", e.Code, http.StatusText(e.Code), body)
+}
+
+// StatusCode implements StatusCoder, so a Server proxying the call replies
+// with the same status code when the error is passed through.
+func (e *HTTPError) StatusCode() int {
+	return e.Code
+}
+
+// DecodeHTTPError returns a function that turns a non-2xx response into an
+// *HTTPError, and returns nil for any 2xx response. At most maxBodySize bytes
+// of the body are captured; a value less than or equal to zero means
+// DefaultMaxErrorBodySize. The body is not closed.
+//
+// If the context of the request was marked with gkit.WithDebug, the request
+// is captured in the Request field of the error as well, with the same bound
+// on its body. The body can only be captured if the request has GetBody set,
+// as EncodeJSONRequest does.
+func DecodeHTTPError(maxBodySize int) func(*http.Response) error {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxErrorBodySize
+	}
+
+	return func(resp *http.Response) error {
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBodySize)+1))
+		if err != nil {
+			return err
+		}
+
+		httpErr := &HTTPError{Code: resp.StatusCode, Header: resp.Header, Body: body}
+		if len(body) > maxBodySize {
+			httpErr.Body, httpErr.Truncated = body[:maxBodySize], true
+		}
+		if resp.Request != nil && gkit.DebugFromContext(resp.Request.Context()) {
+			httpErr.Request = captureRequest(resp.Request, maxBodySize)
+		}
+
+		return httpErr
+	}
+}
+
+func captureRequest(r *http.Request, maxBodySize int) *CapturedRequest {
+	u := *r.URL
+	query := u.Query()
+	for name := range query {
+		if containsFold(RedactedQueryParams, name) {
+			query[name] = []string{Redacted}
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	c := &CapturedRequest{Method: r.Method, URL: u.String(), Header: r.Header.Clone()}
+	for name := range c.Header {
+		if containsFold(RedactedHeaders, name) {
+			c.Header[name] = []string{Redacted}
+		}
+	}
+
+	if r.GetBody != nil {
+		if body, err := r.GetBody(); err == nil {
+			defer body.Close()
+			b, _ := io.ReadAll(io.LimitReader(body, int64(maxBodySize)+1))
+			c.Body = b
+			if len(b) > maxBodySize {
+				c.Body, c.Truncated = b[:maxBodySize], true
+			}
+		}
+	}
+
+	return c
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayRequest re-issues a captured request through client, e.g. built from
+// the Request of an *HTTPError found in the logs, to reproduce a failure. As
+// secrets were redacted, headers and query parameters holding Redacted are
+// dropped; before funcs such as SetRequestBearerToken can set them again.
+func ReplayRequest(ctx context.Context, client HTTPClient, captured *CapturedRequest, before ...RequestFunc) (*http.Response, error) {
+	if captured.Truncated {
+		return nil, ErrTruncatedRequest
+	}
+
+	u, err := url.Parse(captured.URL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	for name, values := range query {
+		if len(values) == 1 && values[0] == Redacted {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, captured.Method, u.String(), bytes.NewReader(captured.Body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range captured.Header {
+		if len(values) == 1 && values[0] == Redacted {
+			continue
+		}
+		req.Header[name] = values
+	}
+	for _, f := range before {
+		ctx = f(ctx, req)
+	}
+
+	return client.Do(req.WithContext(ctx))
+}
+
+// BodyReadError is returned by a Client endpoint when its decoder fails after
+// reading the response body failed, e.g. the connection was reset mid-stream
+// or the body was shorter than its Content-Length. Unlike a malformed payload,
+// it's usually worth retrying, e.g. by checking for it with errors.As in the
+// retryable func given to gkit.Retry.
+type BodyReadError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BodyReadError) Error() string {
+	return "http: reading response body: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying read error.
+func (e *BodyReadError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode implements StatusCoder, so a Server proxying the call replies
+// with 502 Bad Gateway.
+func (e *BodyReadError) StatusCode() int {
+	return http.StatusBadGateway
+}
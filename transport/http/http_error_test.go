@@ -0,0 +1,112 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestDecodeHTTPError(t *testing.T) {
+	newResponse := func(code int, body string) *http.Response {
+		return &http.Response{StatusCode: code, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	if err := httptransport.DecodeHTTPError(0)(newResponse(http.StatusOK, "ok")); err != nil {
+		t.Fatalf("want no error on 2xx, have %v", err)
+	}
+
+	err := httptransport.DecodeHTTPError(4)(newResponse(http.StatusBadGateway, "upstream down"))
+
+	var httpErr *httptransport.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("want *HTTPError, have %T", err)
+	}
+	if want, have := http.StatusBadGateway, httpErr.StatusCode(); want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "upst", string(httpErr.Body); want != have {
+		t.Errorf("Body: want %q, have %q", want, have)
+	}
+	if !httpErr.Truncated {
+		t.Error("want Truncated")
+	}
+	if want, have := "upst...", err.Error(); !strings.HasSuffix(have, want) {
+		t.Errorf("Error: want suffix %q, have %q", want, have)
+	}
+
+	err = httptransport.DecodeHTTPError(0)(newResponse(http.StatusNotFound, "missing"))
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("want *HTTPError, have %T", err)
+	}
+	if httpErr.Truncated {
+		t.Error("want not Truncated")
+	}
+}
+
+func TestReplayRequest(t *testing.T) {
+	type seen struct {
+		auth, query, body string
+	}
+
+	var requests []seen
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, seen{r.Header.Get("Authorization"), r.URL.RawQuery, string(b)})
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL+"/orders?page=2&token=secret"),
+		httptransport.EncodeJSONRequest[map[string]int],
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientBefore[map[string]int, struct{}](httptransport.SetRequestBearerToken("abc")),
+		httptransport.ClientErrorDecoder[map[string]int, struct{}](httptransport.DecodeHTTPError(0)),
+	)
+
+	_, err := client.Endpoint()(context.Background(), map[string]int{"qty": 1})
+	var httpErr *httptransport.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Request != nil {
+		t.Fatalf("want *HTTPError without request outside debug, have %v", err)
+	}
+
+	_, err = client.Endpoint()(gkit.WithDebug(context.Background()), map[string]int{"qty": 1})
+	if !errors.As(err, &httpErr) || httpErr.Request == nil {
+		t.Fatalf("want *HTTPError with request, have %v", err)
+	}
+
+	captured := httpErr.Request
+	if want, have := httptransport.Redacted, captured.Header.Get("Authorization"); want != have {
+		t.Errorf("Authorization: want %q, have %q", want, have)
+	}
+	if strings.Contains(captured.URL, "secret") {
+		t.Errorf("URL: want token redacted, have %s", captured.URL)
+	}
+	if want, have := "{\"qty\":1}\n", string(captured.Body); want != have {
+		t.Errorf("Body: want %q, have %q", want, have)
+	}
+
+	resp, err := httptransport.ReplayRequest(context.Background(), http.DefaultClient, captured, httptransport.SetRequestBearerToken("fresh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if want, have := (seen{"Bearer fresh", "page=2", "{\"qty\":1}\n"}), requests[len(requests)-1]; want != have {
+		t.Errorf("replayed request: want %+v, have %+v", want, have)
+	}
+
+	if _, err := httptransport.ReplayRequest(context.Background(), http.DefaultClient, &httptransport.CapturedRequest{Truncated: true}); !errors.Is(err, httptransport.ErrTruncatedRequest) {
+		t.Errorf("want %v, have %v", httptransport.ErrTruncatedRequest, err)
+	}
+}
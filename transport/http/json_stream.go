@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// JSONStream iterates over the elements of a JSON array request body without
+// loading the whole array into memory. It is produced by
+// DecodeJSONStreamRequest and is meant to be consumed once by the endpoint.
+type JSONStream[T any] struct {
+	ctx context.Context
+	dec *json.Decoder
+}
+
+// Each decodes the elements of the array one by one and calls fn for each of
+// them, stopping at the first error returned by fn or encountered while
+// decoding. It also stops when the request context is done.
+func (s *JSONStream[T]) Each(fn func(T) error) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("http: expected JSON array, got %v", tok)
+	}
+
+	for s.dec.More() {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+
+		var elem T
+		if err := s.dec.Decode(&elem); err != nil {
+			return err
+		}
+
+		if err := fn(elem); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.dec.Token()
+
+	return err
+}
+
+// DecodeJSONStreamRequest returns a DecodeRequestFunc that lets the endpoint
+// stream the elements of a JSON array body through a JSONStream. If maxBytes
+// is greater than zero, reading more than maxBytes from the body fails with an
+// *http.MaxBytesError. The body is closed by the HTTP server once the endpoint
+// returns, so the stream must not be used after that.
+func DecodeJSONStreamRequest[T any](maxBytes int64) gkit.EncodeDecodeFunc[*http.Request, *JSONStream[T]] {
+	return func(ctx context.Context, r *http.Request) (*JSONStream[T], error) {
+		var body io.Reader = r.Body
+		if maxBytes > 0 {
+			body = http.MaxBytesReader(nil, r.Body, maxBytes)
+		}
+		return &JSONStream[T]{ctx: ctx, dec: json.NewDecoder(body)}, nil
+	}
+}
@@ -0,0 +1,62 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestDecodeJSONStreamRequest(t *testing.T) {
+	var sum int
+	handler := httptransport.NewServer(
+		func(_ context.Context, stream *httptransport.JSONStream[fooRequest]) (int, error) {
+			n := 0
+			err := stream.Each(func(elem fooRequest) error {
+				n += len(elem.Foo)
+				return nil
+			})
+			sum = n
+			return n, err
+		},
+		httptransport.DecodeJSONStreamRequest[fooRequest](0),
+		httptransport.EncodeJSONResponse[int],
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"foo":"a"},{"foo":"bb"},{"foo":"ccc"}]`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if want, have := http.StatusOK, rec.Code; want != have {
+		t.Fatalf("StatusCode: want %d, have %d (%s)", want, have, rec.Body)
+	}
+	if want, have := 6, sum; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestDecodeJSONStreamRequestMaxBytes(t *testing.T) {
+	var streamErr error
+	handler := httptransport.NewServer(
+		func(_ context.Context, stream *httptransport.JSONStream[fooRequest]) (struct{}, error) {
+			streamErr = stream.Each(func(fooRequest) error { return nil })
+			return struct{}{}, streamErr
+		},
+		httptransport.DecodeJSONStreamRequest[fooRequest](16),
+		httptransport.EncodeJSONResponse[struct{}],
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"foo":"a"},{"foo":"bb"},{"foo":"ccc"}]`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(streamErr, &maxBytesErr) {
+		t.Errorf("want *http.MaxBytesError, have %v", streamErr)
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MethodOverride returns a RequestFunc, usable with ServerBefore, letting
+// clients that can only send POST, such as HTML forms or clients behind
+// restrictive proxies, ask for another method with the
+// X-HTTP-Method-Override header or, for form-encoded bodies, a _method form
+// field. Only POST requests are overridden, and only to one of allowed, which
+// defaults to PUT, PATCH and DELETE; other values are ignored.
+//
+// The override updates r.Method and, if already populated by
+// PopulateRequestContext, ContextKeyRequestMethod. Since it runs once the
+// request is routed, routers need to match the POST route to this server.
+func MethodOverride(allowed ...string) func(context.Context, *http.Request) context.Context {
+	if len(allowed) == 0 {
+		allowed = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if r.Method != http.MethodPost {
+			return ctx
+		}
+
+		method := r.Header.Get("X-HTTP-Method-Override")
+		if method == "" {
+			if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "application/x-www-form-urlencoded" {
+				method = r.PostFormValue("_method")
+			}
+		}
+		method = strings.ToUpper(strings.TrimSpace(method))
+
+		for _, m := range allowed {
+			if method == m {
+				r.Method = method
+				if _, ok := ctx.Value(ContextKeyRequestMethod).(string); ok {
+					ctx = context.WithValue(ctx, ContextKeyRequestMethod, method)
+				}
+				break
+			}
+		}
+		return ctx
+	}
+}
@@ -0,0 +1,59 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestMethodOverride(t *testing.T) {
+	override := httptransport.MethodOverride()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		header string
+		form   string
+		want   string
+	}{
+		{"header", http.MethodPost, "DELETE", "", http.MethodDelete},
+		{"lowercase header", http.MethodPost, "put", "", http.MethodPut},
+		{"form field", http.MethodPost, "", "_method=PATCH&name=x", http.MethodPatch},
+		{"not allowed", http.MethodPost, "CONNECT", "", http.MethodPost},
+		{"not a POST", http.MethodGet, "DELETE", "", http.MethodGet},
+	} {
+		r := httptest.NewRequest(tc.method, "/", strings.NewReader(tc.form))
+		if tc.header != "" {
+			r.Header.Set("X-HTTP-Method-Override", tc.header)
+		}
+		if tc.form != "" {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		ctx := httptransport.PopulateRequestContext(context.Background(), r)
+		ctx = override(ctx, r)
+
+		if have := r.Method; tc.want != have {
+			t.Errorf("%s: r.Method: want %s, have %s", tc.name, tc.want, have)
+		}
+		if have := ctx.Value(httptransport.ContextKeyRequestMethod); tc.want != have {
+			t.Errorf("%s: context: want %s, have %v", tc.name, tc.want, have)
+		}
+	}
+}
+
+func TestMethodOverrideAllowed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-HTTP-Method-Override", http.MethodPut)
+
+	httptransport.MethodOverride(http.MethodDelete)(context.Background(), r)
+	if want, have := http.MethodPost, r.Method; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+// resilience is applied unchanged on both the server and the client side.
+func resilience[Req, Res any]() gkit.Middleware[Req, Res] {
+	return gkit.Chain(
+		gkit.Retry[Req, Res](2, nil, nil),
+		gkit.Timeout[Req, Res](50*time.Millisecond),
+	)
+}
+
+func TestMiddlewareOnServerAndClient(t *testing.T) {
+	// The handler of a call the client gave up on may still be running when
+	// the test moves on, so the state is shared through atomics.
+	var (
+		serverCalls atomic.Int32
+		slow        atomic.Bool
+	)
+	slow.Store(true)
+
+	business := func(ctx context.Context, _ struct{}) (string, error) {
+		if serverCalls.Add(1) == 1 {
+			return "", errors.New("transient")
+		}
+		if slow.Load() {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "ok", nil
+	}
+
+	server := httptest.NewServer(httptransport.NewServer(
+		resilience[struct{}, string]()(business),
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		httptransport.EncodeJSONResponse[string],
+	))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, r *http.Response) (string, error) {
+			if err := httptransport.DecodeHTTPError(0)(r); err != nil {
+				return "", err
+			}
+			return "ok", nil
+		},
+	)
+	endpoint := resilience[struct{}, string]()(client.Endpoint())
+
+	// Server side: the first failure is retried, then every attempt times out.
+	if _, err := endpoint(context.Background(), struct{}{}); err == nil {
+		t.Fatal("want error, have none")
+	}
+
+	slow.Store(false)
+	serverCalls.Store(1)
+	if _, err := endpoint(context.Background(), struct{}{}); err != nil {
+		t.Fatalf("want no error, have %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sync"
+)
+
+// EncodeMultipartRequest returns an EncodeRequestFunc writing a
+// multipart/form-data body, e.g. to upload files. build adds the fields and
+// file parts of the request to the writer; it must not close it. The body is
+// buffered, so Content-Length is set and it's replayed on redirects and
+// retries. Use EncodeMultipartRequestStream for large files that shouldn't be
+// held in memory.
+func EncodeMultipartRequest[Req any](build func(Req, *multipart.Writer) error) EncodeRequestFunc[Req] {
+	return func(_ context.Context, r *http.Request, request Req) error {
+		var b bytes.Buffer
+		mw := multipart.NewWriter(&b)
+		if err := build(request, mw); err != nil {
+			return err
+		}
+		if err := mw.Close(); err != nil {
+			return err
+		}
+
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+
+		body := b.Bytes()
+		r.ContentLength = int64(len(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		r.Body, _ = r.GetBody()
+
+		return nil
+	}
+}
+
+// EncodeMultipartRequestStream is like EncodeMultipartRequest, but build runs
+// in a goroutine writing to the request body through an io.Pipe as it's sent,
+// so file parts are streamed rather than buffered. The length is unknown, so
+// the body is sent chunked. GetBody runs build again with the same boundary,
+// which replays the body on redirects and retries as long as build writes the
+// same parts every time, e.g. by reopening the files. The goroutine only
+// starts once the body is first read, so a request that's never sent doesn't
+// leak it; closing the body stops it.
+func EncodeMultipartRequestStream[Req any](build func(Req, *multipart.Writer) error) EncodeRequestFunc[Req] {
+	return func(_ context.Context, r *http.Request, request Req) error {
+		boundary := multipart.NewWriter(nil).Boundary()
+
+		r.GetBody = func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+			mw := multipart.NewWriter(pw)
+			if err := mw.SetBoundary(boundary); err != nil {
+				return nil, err
+			}
+
+			return &lazyPipe{PipeReader: pr, start: func() {
+				go func() {
+					err := build(request, mw)
+					if err == nil {
+						err = mw.Close()
+					}
+					pw.CloseWithError(err)
+				}()
+			}}, nil
+		}
+
+		body, err := r.GetBody()
+		if err != nil {
+			return err
+		}
+		r.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+		r.ContentLength = -1
+		r.Body = body
+
+		return nil
+	}
+}
+
+// lazyPipe is the read end of a pipe whose writer is started by the first
+// Read, so nothing is left blocked writing when the body is never read.
+type lazyPipe struct {
+	*io.PipeReader
+	once  sync.Once
+	start func()
+}
+
+func (p *lazyPipe) Read(b []byte) (int, error) {
+	p.once.Do(p.start)
+	return p.PipeReader.Read(b)
+}
+
+// Close closes the pipe, making a started writer fail, and keeps an unstarted
+// one from starting.
+func (p *lazyPipe) Close() error {
+	p.once.Do(func() {})
+	return p.PipeReader.Close()
+}
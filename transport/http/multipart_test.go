@@ -0,0 +1,116 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type upload struct {
+	Title   string
+	Name    string
+	Content string
+}
+
+func buildUpload(req upload, mw *multipart.Writer) error {
+	if err := mw.WriteField("title", req.Title); err != nil {
+		return err
+	}
+	part, err := mw.CreateFormFile("file", req.Name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, strings.NewReader(req.Content))
+	return err
+}
+
+func TestEncodeMultipartRequest(t *testing.T) {
+	type received struct {
+		title, filename, content string
+		contentLength            int64
+	}
+
+	var have []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+			return
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		have = append(have, received{r.FormValue("title"), header.Filename, string(content), r.ContentLength})
+	}))
+	defer server.Close()
+
+	for name, enc := range map[string]httptransport.EncodeRequestFunc[upload]{
+		"buffered": httptransport.EncodeMultipartRequest(buildUpload),
+		"stream":   httptransport.EncodeMultipartRequestStream(buildUpload),
+	} {
+		have = nil
+		client := httptransport.NewClient(
+			http.MethodPost,
+			mustParse(server.URL+"/old"),
+			enc,
+			func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+		)
+
+		code, err := client.Endpoint()(context.Background(), upload{Title: "notes", Name: "notes.txt", Content: "hello"})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if want := http.StatusOK; want != code {
+			t.Errorf("%s: StatusCode: want %d, have %d", name, want, code)
+		}
+		if len(have) != 1 {
+			t.Fatalf("%s: want 1 upload received after the redirect, have %d", name, len(have))
+		}
+		if want := (received{"notes", "notes.txt", "hello", have[0].contentLength}); want != have[0] {
+			t.Errorf("%s: want %+v, have %+v", name, want, have[0])
+		}
+		if name == "buffered" && have[0].contentLength <= 0 {
+			t.Errorf("%s: want Content-Length set, have %d", name, have[0].contentLength)
+		}
+	}
+}
+
+func TestEncodeMultipartRequestStreamUnsent(t *testing.T) {
+	started := make(chan struct{}, 1)
+	enc := httptransport.EncodeMultipartRequestStream(func(req upload, mw *multipart.Writer) error {
+		started <- struct{}{}
+		return buildUpload(req, mw)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if err := enc(context.Background(), r, upload{Title: "notes"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-started:
+		t.Error("want build not started for a body that's never read")
+	default:
+	}
+	if _, err := r.Body.Read(make([]byte, 1)); err == nil {
+		t.Error("want a read error on the closed body, have none")
+	}
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Pagination holds normalized pagination parameters. Page starts at 1, and
+// Offset is always consistent with Page and Limit.
+type Pagination struct {
+	Page   int
+	Limit  int
+	Offset int
+}
+
+// PaginationConfig configures ServerPagination.
+type PaginationConfig struct {
+	// DefaultLimit is used when the request has no limit parameter.
+	// Defaults to 20.
+	DefaultLimit int
+
+	// MaxLimit is the largest limit allowed; larger limits are clamped to it.
+	// Defaults to 100.
+	MaxLimit int
+}
+
+type paginationKey struct{}
+
+type paginationResult struct {
+	p   Pagination
+	err error
+}
+
+// PaginationFromContext returns the Pagination stored by ServerPagination.
+func PaginationFromContext(ctx context.Context) (Pagination, bool) {
+	res, ok := ctx.Value(paginationKey{}).(paginationResult)
+	return res.p, ok && res.err == nil
+}
+
+// ParsePagination reads the page and limit, or offset and limit, query
+// parameters of the request and normalizes them according to cfg. Values that
+// are not integers, a page or limit lower than 1, and a negative offset are
+// reported in a *BindError.
+func ParsePagination(r *http.Request, cfg PaginationConfig) (Pagination, error) {
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = 20
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 100
+	}
+
+	var (
+		query = r.URL.Query()
+		errs  []FieldError
+	)
+	param := func(name string, def, min int) int {
+		raw := query.Get(name)
+		if raw == "" {
+			return def
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < min {
+			errs = append(errs, FieldError{Field: name, Message: "must be an integer greater than or equal to " + strconv.Itoa(min)})
+		}
+		return n
+	}
+
+	p := Pagination{
+		Limit: param("limit", cfg.DefaultLimit, 1),
+		Page:  param("page", 1, 1),
+	}
+	offset := param("offset", -1, 0)
+	if len(errs) > 0 {
+		return Pagination{}, &BindError{Fields: errs}
+	}
+
+	if p.Limit > cfg.MaxLimit {
+		p.Limit = cfg.MaxLimit
+	}
+	if offset >= 0 {
+		p.Offset, p.Page = offset, offset/p.Limit+1
+	} else {
+		p.Offset = (p.Page - 1) * p.Limit
+	}
+
+	return p, nil
+}
+
+// ServerPagination parses the pagination parameters of every request with
+// ParsePagination and stores the result in the context, where the endpoint
+// can read it with PaginationFromContext. Invalid parameters are reported
+// before the request is decoded, and result in a 400 with the default error
+// encoder.
+func ServerPagination[Req, Res any](cfg PaginationConfig) ServerOption[Req, Res] {
+	return func(s *Server[Req, Res]) {
+		s.before = append(s.before, func(ctx context.Context, r *http.Request) context.Context {
+			p, err := ParsePagination(r, cfg)
+			return context.WithValue(ctx, paginationKey{}, paginationResult{p, err})
+		})
+
+		dec := s.dec
+		s.dec = func(ctx context.Context, r *http.Request) (Req, error) {
+			if res, ok := ctx.Value(paginationKey{}).(paginationResult); ok && res.err != nil {
+				var req Req
+				return req, res.err
+			}
+			return dec(ctx, r)
+		}
+	}
+}
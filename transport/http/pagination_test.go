@@ -0,0 +1,50 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestServerPagination(t *testing.T) {
+	var have httptransport.Pagination
+	handler := httptransport.NewServer(
+		func(ctx context.Context, _ struct{}) (struct{}, error) {
+			have, _ = httptransport.PaginationFromContext(ctx)
+			return struct{}{}, nil
+		},
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		httptransport.EncodeJSONResponse[struct{}],
+		httptransport.ServerPagination[struct{}, struct{}](httptransport.PaginationConfig{DefaultLimit: 10, MaxLimit: 50}),
+	)
+
+	for _, test := range []struct {
+		query string
+		code  int
+		want  httptransport.Pagination
+	}{
+		{"", http.StatusOK, httptransport.Pagination{Page: 1, Limit: 10, Offset: 0}},
+		{"page=3&limit=20", http.StatusOK, httptransport.Pagination{Page: 3, Limit: 20, Offset: 40}},
+		{"offset=100&limit=500", http.StatusOK, httptransport.Pagination{Page: 3, Limit: 50, Offset: 100}},
+		{"page=0", http.StatusBadRequest, httptransport.Pagination{}},
+		{"limit=abc", http.StatusBadRequest, httptransport.Pagination{}},
+		{"offset=-1", http.StatusBadRequest, httptransport.Pagination{}},
+	} {
+		have = httptransport.Pagination{}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+test.query, nil))
+
+		if want, have := test.code, rec.Code; want != have {
+			t.Errorf("%q: StatusCode: want %d, have %d", test.query, want, have)
+		}
+		if want := test.want; want != have {
+			t.Errorf("%q: want %+v, have %+v", test.query, want, have)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// PartialHeader is set to "true" by EncodePartial on partial responses.
+const PartialHeader = "X-Partial-Result"
+
+// EncodePartial wraps enc, setting the PartialHeader header of responses
+// implementing gkit.Partialer whose Partial method returns true, e.g. those
+// returned on deadline thanks to gkit.PartialDeadline, so the client can tell
+// them from complete results. The status code is left to enc.
+func EncodePartial[Res any](enc EncodeResponseFunc[Res]) EncodeResponseFunc[Res] {
+	return func(ctx context.Context, w http.ResponseWriter, response Res) error {
+		if p, ok := any(response).(gkit.Partialer); ok && p.Partial() {
+			w.Header().Set(PartialHeader, "true")
+		}
+		return enc(ctx, w, response)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build unit
+
+package http_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestNewPinnedHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	newClient := func(pins ...string) *http.Client {
+		client := httptransport.NewPinnedHTTPClient(pins...)
+		client.Transport.(*http.Transport).TLSClientConfig.RootCAs = roots
+		return client
+	}
+
+	resp, err := newClient("sha256/" + httptransport.PublicKeyPin(server.Certificate())).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	_, err = newClient("bm90IHRoZSByaWdodCBwaW4=").Get(server.URL)
+	if !errors.Is(err, httptransport.ErrCertificatePinMismatch) {
+		t.Errorf("want %v, have %v", httptransport.ErrCertificatePinMismatch, err)
+	}
+}
+
+// newCert returns a certificate for 127.0.0.1 signed by parent, or
+// self-signed when parent is nil.
+func newCert(t *testing.T, parent *tls.Certificate, isCA bool) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "gkit test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	issuer, signer := template, any(key)
+	if parent != nil {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestNewPinnedHTTPClientUnchainedPin(t *testing.T) {
+	ca := newCert(t, nil, true)
+	leaf := newCert(t, &ca, false)
+	pinned := newCert(t, nil, true)
+
+	// The server appends the pinned certificate, which doesn't sign its
+	// chain, to the certificates it sends.
+	leaf.Certificate = append(leaf.Certificate, pinned.Certificate[0])
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{leaf}}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	client := httptransport.NewPinnedHTTPClient(httptransport.PublicKeyPin(pinned.Leaf))
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = roots
+
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, httptransport.ErrCertificatePinMismatch) {
+		t.Errorf("want %v, have %v", httptransport.ErrCertificatePinMismatch, err)
+	}
+
+	client = httptransport.NewPinnedHTTPClient(httptransport.PublicKeyPin(ca.Leaf))
+	client.Transport.(*http.Transport).TLSClientConfig.RootCAs = roots
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("pinned CA of the chain: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ProgressFunc is called as a request body is streamed to the server, with
+// the number of bytes sent so far and the total size of the body. The total is
+// -1 when the size is unknown, e.g. for chunked uploads.
+type ProgressFunc func(bytesSent, total int64)
+
+// SetUploadProgress returns a RequestFunc that reports the progress of the
+// request body through fn. It must run after the body has been encoded, so it
+// works with any EncodeRequestFunc when used via ClientBefore. If the request
+// is replayable, bodies returned by GetBody are reported as well, starting
+// again from zero.
+func SetUploadProgress(fn ProgressFunc) RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if r.Body == nil || r.Body == http.NoBody {
+			return ctx
+		}
+
+		total := r.ContentLength
+		if total <= 0 {
+			total = -1
+		}
+
+		r.Body = &progressReader{ReadCloser: r.Body, total: total, fn: fn}
+		if getBody := r.GetBody; getBody != nil {
+			r.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return &progressReader{ReadCloser: body, total: total, fn: fn}, nil
+			}
+		}
+
+		return ctx
+	}
+}
+
+// progressReader counts the bytes read from the wrapped body and reports them
+// to fn after every read.
+type progressReader struct {
+	io.ReadCloser
+
+	sent  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.ReadCloser.Read(p)
+	if n > 0 {
+		pr.sent += int64(n)
+		pr.fn(pr.sent, pr.total)
+	}
+	return n, err
+}
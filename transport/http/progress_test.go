@@ -0,0 +1,68 @@
+//go:build unit
+
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestSetUploadProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	payload := strings.Repeat("x", 64<<10)
+
+	for _, test := range []struct {
+		name  string
+		enc   httptransport.EncodeRequestFunc[string]
+		total int64
+	}{
+		{
+			name: "known length",
+			enc: func(_ context.Context, r *http.Request, request string) error {
+				r.Body, r.ContentLength = io.NopCloser(strings.NewReader(request)), int64(len(request))
+				return nil
+			},
+			total: int64(len(payload)),
+		},
+		{
+			name: "unknown length",
+			enc: func(_ context.Context, r *http.Request, request string) error {
+				r.Body = io.NopCloser(bytes.NewBufferString(request))
+				return nil
+			},
+			total: -1,
+		},
+	} {
+		var sent, total int64
+		client := httptransport.NewClient(
+			http.MethodPost,
+			mustParse(server.URL),
+			test.enc,
+			func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+			httptransport.ClientBefore[string, struct{}](httptransport.SetUploadProgress(func(s, t int64) {
+				sent, total = s, t
+			})),
+		)
+
+		if _, err := client.Endpoint()(context.Background(), payload); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if want, have := int64(len(payload)), sent; want != have {
+			t.Errorf("%s: sent: want %d, have %d", test.name, want, have)
+		}
+		if want, have := test.total, total; want != have {
+			t.Errorf("%s: total: want %d, have %d", test.name, want, have)
+		}
+	}
+}
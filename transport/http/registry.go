@@ -0,0 +1,58 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// EndpointInfo describes an endpoint registered with a Registry.
+type EndpointInfo struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Registry records metadata about the endpoints explicitly registered with it,
+// and can expose that metadata as JSON for operational tooling or generated
+// documentation. Nothing is recorded unless Register is called.
+type Registry struct {
+	mu        sync.RWMutex
+	endpoints []EndpointInfo
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register records the endpoint served by h under method and path, and
+// returns h unchanged so it can be used inline when mounting routes.
+func (r *Registry) Register(method, path, name string, h http.Handler) http.Handler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.endpoints = append(r.endpoints, EndpointInfo{Name: name, Method: method, Path: path})
+
+	return h
+}
+
+// Endpoints returns the registered endpoints in registration order.
+func (r *Registry) Endpoints() []EndpointInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]EndpointInfo(nil), r.endpoints...)
+}
+
+// Handler returns an http.Handler that responds with the registered endpoints
+// encoded as a JSON array.
+func (r *Registry) Handler() http.Handler {
+	return NewServer(
+		func(context.Context, struct{}) ([]EndpointInfo, error) { return r.Endpoints(), nil },
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		EncodeJSONResponse[[]EndpointInfo],
+	)
+}
@@ -0,0 +1,39 @@
+//go:build unit
+
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := httptransport.NewRegistry()
+
+	mux := http.NewServeMux()
+	mux.Handle("/users", registry.Register(http.MethodGet, "/users", "ListUsers", http.NotFoundHandler()))
+	mux.Handle("/debug/endpoints", registry.Handler())
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/debug/endpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var endpoints []httptransport.EndpointInfo
+	if err := json.NewDecoder(resp.Body).Decode(&endpoints); err != nil {
+		t.Fatal(err)
+	}
+
+	want := httptransport.EndpointInfo{Name: "ListUsers", Method: http.MethodGet, Path: "/users"}
+	if len(endpoints) != 1 || endpoints[0] != want {
+		t.Errorf("want [%v], have %v", want, endpoints)
+	}
+}
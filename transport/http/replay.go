@@ -0,0 +1,174 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// DefaultMaxReplayBodySize is the default maximum size of the response bodies
+// stored by Replay.
+const DefaultMaxReplayBodySize = 1 << 20
+
+type replayConfig struct {
+	maxBytes int64
+}
+
+// ReplayOption sets an optional parameter for Replay.
+type ReplayOption gkit.Option[*replayConfig]
+
+// ReplayMaxBodyBytes bounds the size of the response bodies Replay stores; a
+// response with a larger body is sent but not stored. Zero or less means no
+// limit.
+func ReplayMaxBodyBytes(n int64) ReplayOption {
+	return func(c *replayConfig) { c.maxBytes = n }
+}
+
+// replayedResponse is the serialized form of a response stored by Replay.
+type replayedResponse struct {
+	Code   int         `json:"code"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Replay returns a middleware that, within ttl, answers a request whose key
+// was already seen with the exact response of the first request (status,
+// headers and body) instead of handling it again. This is stronger than
+// caching the endpoint response, and suits idempotent POST handling, e.g.
+// keyed by the Idempotency-Key header. Requests for which key returns an
+// empty string always reach h. Responses with a 5xx status are not stored, so
+// the request can be retried, and neither are those of a hijacked
+// connection or with a body larger than DefaultMaxReplayBodySize, or the size
+// given with ReplayMaxBodyBytes. Concurrent duplicates are not coalesced; use
+// gkit.Coalesce for that. Cache errors are treated as misses.
+//
+// Set-Cookie headers are never stored, so a session cookie issued to the
+// first caller isn't handed to whoever replays its key.
+//
+// The ResponseWriter given to h implements http.Flusher and http.Hijacker if
+// w does, so streaming handlers keep working behind Replay.
+func Replay(cache gkit.Cache, ttl time.Duration, key func(*http.Request) string, options ...ReplayOption) func(http.Handler) http.Handler {
+	c := replayConfig{maxBytes: DefaultMaxReplayBodySize}
+	for _, option := range options {
+		option(&c)
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if b, ok, err := cache.Get(r.Context(), k); err == nil && ok {
+				var stored replayedResponse
+				if err := json.Unmarshal(b, &stored); err == nil {
+					for name, values := range stored.Header {
+						w.Header()[name] = values
+					}
+					w.WriteHeader(stored.Code)
+					w.Write(stored.Body) //nolint:errcheck
+					return
+				}
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, code: http.StatusOK, maxBytes: c.maxBytes}
+			h.ServeHTTP(rw.reimplementInterfaces(), r)
+
+			if rw.code >= 500 || rw.hijacked || rw.truncated {
+				return
+			}
+			header := w.Header().Clone()
+			header.Del("Set-Cookie")
+			b, err := json.Marshal(replayedResponse{Code: rw.code, Header: header, Body: rw.body.Bytes()})
+			if err != nil {
+				return
+			}
+			cache.Set(r.Context(), k, b, ttl) //nolint:errcheck
+		})
+	}
+}
+
+// recordingWriter keeps a copy of the status code and body written through
+// it. Once the body exceeds maxBytes, if greater than zero, the copy is
+// dropped and truncated is set.
+type recordingWriter struct {
+	http.ResponseWriter
+	code      int
+	body      bytes.Buffer
+	maxBytes  int64
+	truncated bool
+	hijacked  bool
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if !w.truncated {
+		if w.maxBytes > 0 && int64(w.body.Len()+len(p)) > w.maxBytes {
+			w.truncated = true
+			w.body = bytes.Buffer{}
+		} else {
+			w.body.Write(p)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recordingWriter) flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *recordingWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// reimplementInterfaces returns w, also implementing http.Flusher and
+// http.Hijacker if the wrapped ResponseWriter does, in the manner of
+// interceptingWriter.reimplementInterfaces. io.ReaderFrom isn't passed
+// through, as the body would bypass the recording.
+func (w *recordingWriter) reimplementInterfaces() http.ResponseWriter {
+	_, fl := w.ResponseWriter.(http.Flusher)
+	_, hj := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case fl && hj:
+		return struct {
+			http.ResponseWriter
+			http.Flusher
+			http.Hijacker
+		}{w, flusherFunc(w.flush), hijackerFunc(w.hijack)}
+	case fl:
+		return struct {
+			http.ResponseWriter
+			http.Flusher
+		}{w, flusherFunc(w.flush)}
+	case hj:
+		return struct {
+			http.ResponseWriter
+			http.Hijacker
+		}{w, hijackerFunc(w.hijack)}
+	default:
+		return struct {
+			http.ResponseWriter
+		}{w}
+	}
+}
+
+type flusherFunc func()
+
+func (f flusherFunc) Flush() { f() }
+
+type hijackerFunc func() (net.Conn, *bufio.ReadWriter, error)
+
+func (f hijackerFunc) Hijack() (net.Conn, *bufio.ReadWriter, error) { return f() }
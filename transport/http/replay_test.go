@@ -0,0 +1,148 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type chargeResponse struct {
+	ID int `json:"id"`
+}
+
+func (chargeResponse) StatusCode() int      { return http.StatusCreated }
+func (chargeResponse) Headers() http.Header { return http.Header{"X-Charge": []string{"1"}} }
+
+func TestReplay(t *testing.T) {
+	var calls int
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (chargeResponse, error) {
+			calls++
+			return chargeResponse{ID: calls}, nil
+		},
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		httptransport.EncodeJSONResponse[chargeResponse],
+	)
+
+	replay := httptransport.Replay(gkit.NewMemoryCache(), time.Minute, func(r *http.Request) string {
+		return r.Header.Get("Idempotency-Key")
+	})(handler)
+
+	for i, key := range []string{"k1", "k1", "k2"} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		replay.ServeHTTP(rec, req)
+
+		if want, have := http.StatusCreated, rec.Code; want != have {
+			t.Errorf("%d: StatusCode: want %d, have %d", i, want, have)
+		}
+		if want, have := "1", rec.Header().Get("X-Charge"); want != have {
+			t.Errorf("%d: X-Charge: want %q, have %q", i, want, have)
+		}
+		wantBody := map[string]string{"k1": `{"id":1}`, "k2": `{"id":2}`}[key]
+		if have := strings.TrimSpace(rec.Body.String()); wantBody != have {
+			t.Errorf("%d: Body: want %s, have %s", i, wantBody, have)
+		}
+	}
+
+	if want, have := 2, calls; want != have {
+		t.Errorf("calls: want %d, have %d", want, have)
+	}
+}
+
+func TestReplayFlusher(t *testing.T) {
+	replay := httptransport.Replay(gkit.NewMemoryCache(), time.Minute, func(*http.Request) string {
+		return "k1"
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("want the ResponseWriter to implement http.Flusher")
+		}
+		w.Write([]byte("chunk")) //nolint:errcheck
+		flusher.Flush()
+	}))
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		replay.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if want, have := "chunk", rec.Body.String(); want != have {
+			t.Errorf("%d: Body: want %q, have %q", i, want, have)
+		}
+		if i == 0 && !rec.Flushed {
+			t.Error("want the response flushed")
+		}
+	}
+}
+
+func TestReplayMaxBodyBytes(t *testing.T) {
+	var calls int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(strings.Repeat("x", 8)))
+		w.Write([]byte(r.URL.Query().Get("tail")))
+	})
+
+	replay := httptransport.Replay(gkit.NewMemoryCache(), time.Minute, func(r *http.Request) string {
+		return r.Header.Get("Idempotency-Key")
+	}, httptransport.ReplayMaxBodyBytes(10))(handler)
+
+	for _, tc := range []struct {
+		tail      string
+		wantCalls int
+	}{
+		{"yy", 1}, // 10 bytes, stored
+		{"yy", 1},
+		{"zzz", 2}, // 11 bytes, sent but not stored
+		{"zzz", 3},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/?tail="+tc.tail, nil)
+		req.Header.Set("Idempotency-Key", tc.tail)
+		rec := httptest.NewRecorder()
+		replay.ServeHTTP(rec, req)
+
+		if want, have := strings.Repeat("x", 8)+tc.tail, rec.Body.String(); want != have {
+			t.Errorf("%s: Body: want %s, have %s", tc.tail, want, have)
+		}
+		if want, have := tc.wantCalls, calls; want != have {
+			t.Errorf("%s: calls: want %d, have %d", tc.tail, want, have)
+		}
+	}
+}
+
+func TestReplaySetCookie(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.Header().Set("X-Charge", "1")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	replay := httptransport.Replay(gkit.NewMemoryCache(), time.Minute, func(r *http.Request) string {
+		return r.Header.Get("Idempotency-Key")
+	})(handler)
+
+	for i, wantCookie := range []bool{true, false} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("Idempotency-Key", "k1")
+		rec := httptest.NewRecorder()
+		replay.ServeHTTP(rec, req)
+
+		if want, have := http.StatusCreated, rec.Code; want != have {
+			t.Errorf("%d: StatusCode: want %d, have %d", i, want, have)
+		}
+		if want, have := "1", rec.Header().Get("X-Charge"); want != have {
+			t.Errorf("%d: X-Charge: want %q, have %q", i, want, have)
+		}
+		if have := rec.Header().Get("Set-Cookie") != ""; wantCookie != have {
+			t.Errorf("%d: Set-Cookie: want %v, have %v", i, wantCookie, have)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressionMode sets when a CompressionNegotiator compresses request
+// bodies.
+type CompressionMode int
+
+const (
+	// CompressAuto compresses the request bodies sent to a host once one of
+	// its responses advertised gzip in an Accept-Encoding header, as per RFC
+	// 7694. If the host then answers a compressed request with 415
+	// Unsupported Media Type, the request is sent again uncompressed, and
+	// the host isn't sent compressed bodies anymore, until it advertises
+	// gzip again.
+	CompressAuto CompressionMode = iota
+
+	// CompressAlways compresses every request body, for servers known to
+	// support gzip request bodies.
+	CompressAlways
+
+	// CompressNever never compresses request bodies.
+	CompressNever
+)
+
+// maxCompressionHosts caps the hosts a CompressionNegotiator remembers, so a
+// client calling many distinct hosts doesn't grow it without bound. Once full,
+// an arbitrary host is forgotten for every new one.
+const maxCompressionHosts = 1024
+
+// CompressionNegotiator decides which request bodies a Client compresses with
+// gzip, tracking which hosts accept gzip request bodies so servers unable to
+// decompress them never get any. It may be shared by the Clients calling the
+// same hosts. See ClientRequestCompression.
+type CompressionNegotiator struct {
+	mode CompressionMode
+
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+// NewCompressionNegotiator returns a CompressionNegotiator compressing
+// request bodies according to mode.
+func NewCompressionNegotiator(mode CompressionMode) *CompressionNegotiator {
+	return &CompressionNegotiator{mode: mode, hosts: make(map[string]bool)}
+}
+
+// Accepts reports whether request bodies sent to host are compressed.
+func (n *CompressionNegotiator) Accepts(host string) bool {
+	switch n.mode {
+	case CompressAlways:
+		return true
+	case CompressNever:
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.hosts[host]
+}
+
+// observe records what resp tells of host, and reports whether it rejected
+// a compressed request, which should be sent again uncompressed.
+func (n *CompressionNegotiator) observe(host string, resp *http.Response, compressed bool) (rejected bool) {
+	if n.mode != CompressAuto {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if compressed && resp.StatusCode == http.StatusUnsupportedMediaType {
+		delete(n.hosts, host)
+		return true
+	}
+	if acceptsGzip(resp.Header) && !n.hosts[host] {
+		if len(n.hosts) >= maxCompressionHosts {
+			for h := range n.hosts {
+				delete(n.hosts, h)
+				break
+			}
+		}
+		n.hosts[host] = true
+	}
+	return false
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of h lists gzip
+// with a nonzero weight.
+func acceptsGzip(h http.Header) bool {
+	for _, v := range h.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if q, err := strconv.ParseFloat(v, 64); strings.EqualFold(k, "q") && err == nil && q == 0 {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ClientRequestCompression makes the client compress request bodies with
+// gzip when n says the host accepts them, setting the Content-Encoding
+// header. Requests whose body the EncodeRequestFunc already encoded, e.g.
+// with EncodeGzipJSONRequest, are sent as is, and so are bodies of unknown
+// length, e.g. streamed ones, unless n compresses always, as compressing them
+// means buffering them in memory. Every response is passed to n, so it learns
+// which hosts accept gzip.
+//
+// The body is compressed once per call, and the compressed bytes are reused
+// if the call is retried.
+func ClientRequestCompression[Req, Res any](n *CompressionNegotiator) ClientOption[Req, Res] {
+	return func(c *Client[Req, Res]) { c.compression = n }
+}
+
+// compressClient is built for every call, so it keeps the body of the call
+// once compressed for the retries of the call.
+type compressClient struct {
+	next HTTPClient
+	n    *CompressionNegotiator
+
+	plain, compressed []byte
+}
+
+func (c *compressClient) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	unknownLength := req.ContentLength <= 0 && c.n.mode != CompressAlways
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" || unknownLength || !c.n.Accepts(host) {
+		return c.send(host, req)
+	}
+
+	if c.compressed == nil {
+		plain, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		compressed, err := gzipBytes(plain)
+		if err != nil {
+			return nil, err
+		}
+		c.plain, c.compressed = plain, compressed
+	} else {
+		req.Body.Close()
+	}
+
+	zreq := withBody(req, c.compressed)
+	zreq.Header.Set("Content-Encoding", "gzip")
+	resp, err := c.next.Do(zreq)
+	if err != nil || !c.n.observe(host, resp, true) {
+		return resp, err
+	}
+
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	resp.Body.Close()
+	return c.send(host, withBody(req, c.plain))
+}
+
+// send sends req uncompressed, passing the response to the negotiator.
+func (c *compressClient) send(host string, req *http.Request) (*http.Response, error) {
+	resp, err := c.next.Do(req)
+	if err == nil {
+		c.n.observe(host, resp, false)
+	}
+	return resp, err
+}
+
+// withBody returns a copy of req sending body.
+func withBody(req *http.Request, body []byte) *http.Request {
+	r := req.Clone(req.Context())
+	r.ContentLength = int64(len(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.Body, _ = r.GetBody()
+	return r
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+	zw.Reset(&buf)
+
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,174 @@
+//go:build unit
+
+package http_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestClientRequestCompression(t *testing.T) {
+	var (
+		encodings  []string
+		bodies     []string
+		rejectGzip bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.Header.Get("Content-Encoding")
+		encodings = append(encodings, encoding)
+		if encoding == "gzip" && rejectGzip {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body := io.Reader(r.Body)
+		if encoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		}
+		b, _ := io.ReadAll(body)
+		bodies = append(bodies, strings.TrimSpace(string(b)))
+		if !rejectGzip {
+			w.Header().Set("Accept-Encoding", "gzip, identity")
+		}
+	}))
+	defer server.Close()
+
+	n := httptransport.NewCompressionNegotiator(httptransport.CompressAuto)
+	client := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL),
+		httptransport.EncodeJSONRequest[string],
+		func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+		httptransport.ClientRequestCompression[string, int](n),
+	)
+
+	call := func() {
+		t.Helper()
+		code, err := client.Endpoint()(context.Background(), "payload")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := http.StatusOK, code; want != have {
+			t.Fatalf("StatusCode: want %d, have %d", want, have)
+		}
+	}
+
+	call() // learns that the server accepts gzip
+	call() // compressed
+	rejectGzip = true
+	call() // rejected, sent again uncompressed
+	call() // uncompressed
+
+	if want, have := `["" "gzip" "gzip" "" ""]`, fmt.Sprintf("%q", encodings); want != have {
+		t.Errorf("encodings: want %s, have %s", want, have)
+	}
+	for i, body := range bodies {
+		if want, have := `"payload"`, body; want != have {
+			t.Errorf("request %d: want body %s, have %s", i, want, have)
+		}
+	}
+	if n.Accepts(mustParse(server.URL).Host) {
+		t.Error("want the host marked as not accepting gzip")
+	}
+}
+
+func TestClientRequestCompressionUnknownLength(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		w.Header().Set("Accept-Encoding", "gzip")
+	}))
+	defer server.Close()
+
+	for _, mode := range []httptransport.CompressionMode{httptransport.CompressAuto, httptransport.CompressAlways} {
+		encodings = nil
+		client := httptransport.NewClient(
+			http.MethodPost,
+			mustParse(server.URL),
+			func(_ context.Context, r *http.Request, body string) error {
+				r.Body, r.ContentLength = io.NopCloser(strings.NewReader(body)), -1
+				return nil
+			},
+			func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+			httptransport.ClientRequestCompression[string, int](httptransport.NewCompressionNegotiator(mode)),
+		)
+		for i := 0; i < 2; i++ {
+			if _, err := client.Endpoint()(context.Background(), "payload"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := `["" ""]`
+		if mode == httptransport.CompressAlways {
+			want = `["gzip" "gzip"]`
+		}
+		if have := fmt.Sprintf("%q", encodings); want != have {
+			t.Errorf("mode %d: encodings: want %s, have %s", mode, want, have)
+		}
+	}
+}
+
+func TestClientRequestCompressionRetry(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			t.Error("want a compressed body on every attempt")
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b, _ := io.ReadAll(zr)
+		bodies = append(bodies, strings.TrimSpace(string(b)))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodPut,
+		mustParse(server.URL),
+		httptransport.EncodeJSONRequest[string],
+		func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+		httptransport.ClientRequestCompression[string, int](httptransport.NewCompressionNegotiator(httptransport.CompressAlways)),
+		httptransport.ClientRetry[string, int](1, nil, nil),
+	)
+	code, err := client.Endpoint()(context.Background(), "payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusOK, code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := `["\"payload\"" "\"payload\""]`, fmt.Sprintf("%q", bodies); want != have {
+		t.Errorf("bodies: want %s, have %s", want, have)
+	}
+}
+
+func TestCompressionNegotiatorModes(t *testing.T) {
+	if !httptransport.NewCompressionNegotiator(httptransport.CompressAlways).Accepts("example.com") {
+		t.Error("CompressAlways: want compression")
+	}
+	if httptransport.NewCompressionNegotiator(httptransport.CompressNever).Accepts("example.com") {
+		t.Error("CompressNever: want no compression")
+	}
+	if httptransport.NewCompressionNegotiator(httptransport.CompressAuto).Accepts("example.com") {
+		t.Error("CompressAuto: want no compression for an unknown host")
+	}
+}
@@ -2,7 +2,12 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
+	"strings"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
 )
 
 // RequestFunc may take information from an HTTP request and put it into a
@@ -21,6 +26,41 @@ type ServerResponseFunc func(context.Context, http.ResponseWriter) context.Conte
 // clients, after a request has been made, but prior to it being decoded.
 type ClientResponseFunc func(context.Context, *http.Response) context.Context
 
+// CombineRequestFuncs returns a RequestFunc running fs in order, each
+// receiving the context returned by the previous one, so a bundle of hooks,
+// e.g. tracing, request ID and auth, can be reused as a single unit. Its type
+// is unnamed, so it's accepted by both ClientBefore and ServerBefore.
+func CombineRequestFuncs(fs ...RequestFunc) func(context.Context, *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, f := range fs {
+			ctx = f(ctx, r)
+		}
+		return ctx
+	}
+}
+
+// CombineServerResponseFuncs is like CombineRequestFuncs for
+// ServerResponseFuncs.
+func CombineServerResponseFuncs(fs ...ServerResponseFunc) ServerResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter) context.Context {
+		for _, f := range fs {
+			ctx = f(ctx, w)
+		}
+		return ctx
+	}
+}
+
+// CombineClientResponseFuncs is like CombineRequestFuncs for
+// ClientResponseFuncs.
+func CombineClientResponseFuncs(fs ...ClientResponseFunc) ClientResponseFunc {
+	return func(ctx context.Context, resp *http.Response) context.Context {
+		for _, f := range fs {
+			ctx = f(ctx, resp)
+		}
+		return ctx
+	}
+}
+
 // SetContentType returns a ServerResponseFunc that sets the Content-Type header
 // to the provided value.
 func SetContentType(contentType string) ServerResponseFunc {
@@ -43,30 +83,232 @@ func SetRequestHeader(key, val string) RequestFunc {
 	}
 }
 
+// SetRequestHost returns a RequestFunc that sets r.Host, which is the value
+// the HTTP client sends as the Host header. Setting the "Host" key of
+// r.Header has no effect, as the client ignores it. Unlike changing r.URL,
+// setting r.Host leaves the dialed address untouched, so a request can be sent
+// to a load balancer by IP while naming the intended virtual host.
+func SetRequestHost(host string) RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		r.Host = host
+		return ctx
+	}
+}
+
+// SetRequestCookies returns a RequestFunc that adds the given cookies to the
+// request. The cookies are added to the Cookie header of the request itself,
+// so they are sent in addition to any cookies the underlying *http.Client
+// attaches from its Jar. If the same cookie is present in both, the server
+// receives it twice; prefer one mechanism per cookie.
+func SetRequestCookies(cookies ...*http.Cookie) RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		return ctx
+	}
+}
+
+// SetRequestBasicAuth returns a RequestFunc that sets the Authorization header
+// of the request to use HTTP Basic authentication with the given credentials.
+func SetRequestBasicAuth(username, password string) RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		r.SetBasicAuth(username, password)
+		return ctx
+	}
+}
+
+// SetRequestBearerToken returns a RequestFunc that sets the Authorization
+// header of the request to the given bearer token.
+func SetRequestBearerToken(token string) RequestFunc {
+	return SetRequestHeader("Authorization", "Bearer "+token)
+}
+
+// SetRequestTokenFromContext returns a RequestFunc that sets the Authorization
+// header of the request to the bearer token stored in the context under key,
+// so a shared Client can send a different token on every request. The header
+// is left untouched if the context holds no string token under key.
+func SetRequestTokenFromContext(key any) RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if token, _ := ctx.Value(key).(string); token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return ctx
+	}
+}
+
+// SetRequestDeadline is a RequestFunc for clients that sends the time left
+// until the context deadline, if any, in the gkit.TimeoutKey header, so the
+// server can stop working on the request once the client has given up. Pair
+// it with PopulateRequestDeadline on the server.
+func SetRequestDeadline(ctx context.Context, r *http.Request) context.Context {
+	if deadline, ok := ctx.Deadline(); ok {
+		r.Header.Set(gkit.TimeoutKey, gkit.FormatTimeout(time.Until(deadline)))
+	}
+	return ctx
+}
+
+// PopulateRequestDeadline is a RequestFunc for servers that stores the time
+// budget sent by SetRequestDeadline, or by a gRPC-aware proxy, with
+// gkit.WithPropagatedTimeout. Wrap the endpoint with gkit.PropagateDeadline to
+// apply it. Malformed values are ignored.
+func PopulateRequestDeadline(ctx context.Context, r *http.Request) context.Context {
+	if d, err := gkit.ParseTimeout(r.Header.Get(gkit.TimeoutKey)); err == nil {
+		ctx = gkit.WithPropagatedTimeout(ctx, d)
+	}
+	return ctx
+}
+
+// SetRequestBaggage is a RequestFunc for clients that sends the baggage
+// stored with gkit.WithBaggage in the gkit.BaggageKey header, formatted by
+// gkit.FormatBaggage. Pair it with PopulateRequestBaggage on the server.
+func SetRequestBaggage(ctx context.Context, r *http.Request) context.Context {
+	if b := gkit.FormatBaggage(gkit.BaggageFromContext(ctx)); b != "" {
+		r.Header.Set(gkit.BaggageKey, b)
+	}
+	return ctx
+}
+
+// PopulateRequestBaggage is a RequestFunc for servers that stores the baggage
+// sent by SetRequestBaggage, or by any W3C Baggage propagator, with
+// gkit.WithBaggage. Multiple baggage headers are combined. Malformed members
+// are ignored.
+func PopulateRequestBaggage(ctx context.Context, r *http.Request) context.Context {
+	if b := gkit.ParseBaggage(strings.Join(r.Header.Values(gkit.BaggageKey), ",")); b != nil {
+		ctx = gkit.WithBaggage(ctx, b)
+	}
+	return ctx
+}
+
+// SetRequestNoCache is a RequestFunc for clients that sets the
+// "Cache-Control: no-cache" header when the context was marked with
+// gkit.WithNoCache, e.g. by gkit.Freshness refetching a stale response, so
+// intermediate caches revalidate with the origin.
+func SetRequestNoCache(ctx context.Context, r *http.Request) context.Context {
+	if gkit.NoCacheFromContext(ctx) {
+		r.Header.Set("Cache-Control", "no-cache")
+	}
+	return ctx
+}
+
 // PopulateRequestContext is a RequestFunc that populates several values into
 // the context from the HTTP request. Those values may be extracted using the
-// corresponding ContextKey type in this package.
+// corresponding ContextKey type in this package. A non-empty X-Request-Id is
+// also stored with gkit.WithRequestID.
 func PopulateRequestContext(ctx context.Context, r *http.Request) context.Context {
 	for k, v := range map[contextKey]string{
-		ContextKeyRequestMethod:          r.Method,
-		ContextKeyRequestURI:             r.RequestURI,
-		ContextKeyRequestPath:            r.URL.Path,
-		ContextKeyRequestProto:           r.Proto,
-		ContextKeyRequestHost:            r.Host,
-		ContextKeyRequestRemoteAddr:      r.RemoteAddr,
-		ContextKeyRequestXForwardedFor:   r.Header.Get("X-Forwarded-For"),
-		ContextKeyRequestXForwardedProto: r.Header.Get("X-Forwarded-Proto"),
-		ContextKeyRequestAuthorization:   r.Header.Get("Authorization"),
-		ContextKeyRequestReferer:         r.Header.Get("Referer"),
-		ContextKeyRequestUserAgent:       r.Header.Get("User-Agent"),
-		ContextKeyRequestXRequestID:      r.Header.Get("X-Request-Id"),
-		ContextKeyRequestAccept:          r.Header.Get("Accept"),
+		ContextKeyRequestMethod:            r.Method,
+		ContextKeyRequestURI:               r.RequestURI,
+		ContextKeyRequestPath:              r.URL.Path,
+		ContextKeyRequestProto:             r.Proto,
+		ContextKeyRequestHost:              r.Host,
+		ContextKeyRequestRemoteAddr:        r.RemoteAddr,
+		ContextKeyRequestXForwardedFor:     r.Header.Get("X-Forwarded-For"),
+		ContextKeyRequestXForwardedProto:   r.Header.Get("X-Forwarded-Proto"),
+		ContextKeyRequestAuthorization:     r.Header.Get("Authorization"),
+		ContextKeyRequestReferer:           r.Header.Get("Referer"),
+		ContextKeyRequestUserAgent:         r.Header.Get("User-Agent"),
+		ContextKeyRequestXRequestID:        r.Header.Get("X-Request-Id"),
+		ContextKeyRequestAccept:            r.Header.Get("Accept"),
+		ContextKeyRequestRange:             r.Header.Get("Range"),
+		ContextKeyRequestIfRange:           r.Header.Get("If-Range"),
+		ContextKeyRequestIfNoneMatch:       r.Header.Get("If-None-Match"),
+		ContextKeyRequestIfModifiedSince:   r.Header.Get("If-Modified-Since"),
+		ContextKeyRequestIfMatch:           r.Header.Get("If-Match"),
+		ContextKeyRequestIfUnmodifiedSince: r.Header.Get("If-Unmodified-Since"),
 	} {
 		ctx = context.WithValue(ctx, k, v)
 	}
+	if id := r.Header.Get("X-Request-Id"); id != "" {
+		ctx = gkit.WithRequestID(ctx, id)
+	}
 	return ctx
 }
 
+// PopulateIdempotencyKey is a RequestFunc that stores the Idempotency-Key
+// header of the request in the context with gkit.WithIdempotencyKey, so
+// middlewares such as gkit.Coalesce can key on it.
+func PopulateIdempotencyKey(ctx context.Context, r *http.Request) context.Context {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		ctx = gkit.WithIdempotencyKey(ctx, key)
+	}
+	return ctx
+}
+
+// PopulateCookies is a RequestFunc that parses the cookies of the HTTP
+// request once and stores them in the context under ContextKeyRequestCookies.
+// Use CookieFromContext to read a single cookie afterwards.
+func PopulateCookies(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, ContextKeyRequestCookies, r.Cookies())
+}
+
+// CookieFromContext returns the named cookie stored in the context by
+// PopulateCookies. If the cookie is absent, or PopulateCookies was not used,
+// http.ErrNoCookie is returned.
+func CookieFromContext(ctx context.Context, name string) (*http.Cookie, error) {
+	cookies, _ := ctx.Value(ContextKeyRequestCookies).([]*http.Cookie)
+	for _, c := range cookies {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return nil, http.ErrNoCookie
+}
+
+// PopulateMethodSemantics is a RequestFunc that records in the context whether
+// the HTTP method of the request is safe and whether it is idempotent, as
+// defined by RFC 9110 section 9.2. Middlewares such as retries or caches can
+// then use IsSafeFromContext and IsIdempotentFromContext instead of deriving
+// the semantics from the method themselves.
+func PopulateMethodSemantics(ctx context.Context, r *http.Request) context.Context {
+	ctx = context.WithValue(ctx, ContextKeyRequestSafe, IsSafeMethod(r.Method))
+	return context.WithValue(ctx, ContextKeyRequestIdempotent, IsIdempotentMethod(r.Method))
+}
+
+// IsSafeMethod reports whether the HTTP method is safe, i.e. read-only.
+func IsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsIdempotentMethod reports whether the HTTP method is idempotent. All safe
+// methods are idempotent, as are PUT and DELETE.
+func IsIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return IsSafeMethod(method)
+	}
+}
+
+// IsSafeFromContext reports whether the request method recorded by
+// PopulateMethodSemantics is safe. It returns false if none was recorded.
+func IsSafeFromContext(ctx context.Context) bool {
+	safe, _ := ctx.Value(ContextKeyRequestSafe).(bool)
+	return safe
+}
+
+// IsIdempotentFromContext reports whether the request method recorded by
+// PopulateMethodSemantics is idempotent. It returns false if none was
+// recorded, so callers err on the side of not repeating the request.
+func IsIdempotentFromContext(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(ContextKeyRequestIdempotent).(bool)
+	return idempotent
+}
+
+// TLSStateFromContext returns the TLS connection state of the response,
+// stored in the context under ContextKeyResponseTLS for ClientFinalizerFuncs.
+// It returns nil for plaintext connections.
+func TLSStateFromContext(ctx context.Context) *tls.ConnectionState {
+	state, _ := ctx.Value(ContextKeyResponseTLS).(*tls.ConnectionState)
+	return state
+}
+
 type contextKey int
 
 const (
@@ -130,4 +372,55 @@ const (
 	// ContextKeyResponseSize is populated in the context whenever a
 	// ServerFinalizerFunc is specified. Its value is of type int64.
 	ContextKeyResponseSize
+
+	// ContextKeyRequestCookies is populated in the context by
+	// PopulateCookies. Its value is r.Cookies().
+	ContextKeyRequestCookies
+
+	// ContextKeyRequestSafe is populated in the context by
+	// PopulateMethodSemantics. Its value is IsSafeMethod(r.Method).
+	ContextKeyRequestSafe
+
+	// ContextKeyRequestIdempotent is populated in the context by
+	// PopulateMethodSemantics. Its value is IsIdempotentMethod(r.Method).
+	ContextKeyRequestIdempotent
+
+	// ContextKeyResponseTLS is populated in the context whenever a
+	// ClientFinalizerFunc is specified. Its value is resp.TLS, of type
+	// *tls.ConnectionState, which is nil for plaintext connections.
+	ContextKeyResponseTLS
+
+	// ContextKeyRequestClientIP is populated in the context by
+	// PopulateClientIP. Its value is of type net.IP.
+	ContextKeyRequestClientIP
+
+	// ContextKeyRequestRange is populated in the context by
+	// PopulateRequestContext. Its value is r.Header.Get("Range").
+	ContextKeyRequestRange
+
+	// ContextKeyResponseTrailers is populated in the context whenever a
+	// ClientFinalizerFunc is specified. Its value is resp.Trailer, of type
+	// http.Header, which is only complete once the body has been read to EOF.
+	// See ClientAfterDecode.
+	ContextKeyResponseTrailers
+
+	// ContextKeyRequestIfRange is populated in the context by
+	// PopulateRequestContext. Its value is r.Header.Get("If-Range").
+	ContextKeyRequestIfRange
+
+	// ContextKeyRequestIfNoneMatch is populated in the context by
+	// PopulateRequestContext. Its value is r.Header.Get("If-None-Match").
+	ContextKeyRequestIfNoneMatch
+
+	// ContextKeyRequestIfModifiedSince is populated in the context by
+	// PopulateRequestContext. Its value is r.Header.Get("If-Modified-Since").
+	ContextKeyRequestIfModifiedSince
+
+	// ContextKeyRequestIfMatch is populated in the context by
+	// PopulateRequestContext. Its value is r.Header.Get("If-Match").
+	ContextKeyRequestIfMatch
+
+	// ContextKeyRequestIfUnmodifiedSince is populated in the context by
+	// PopulateRequestContext. Its value is r.Header.Get("If-Unmodified-Since").
+	ContextKeyRequestIfUnmodifiedSince
 )
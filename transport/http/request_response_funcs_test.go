@@ -4,9 +4,14 @@ package http_test
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	gkit "github.com/bobobox-id/gkit/core"
 	httptransport "github.com/bobobox-id/gkit/transport/http"
 )
 
@@ -30,3 +35,236 @@ func TestSetContentType(t *testing.T) {
 		t.Errorf("want %q, have %q", want, have)
 	}
 }
+
+func TestSetRequestCookies(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	httptransport.SetRequestCookies(
+		&http.Cookie{Name: "session", Value: "abc"},
+		&http.Cookie{Name: "lang", Value: "id"},
+	)(context.Background(), r)
+
+	for name, want := range map[string]string{"session": "abc", "lang": "id"} {
+		c, err := r.Cookie(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if have := c.Value; want != have {
+			t.Errorf("%s: want %q, have %q", name, want, have)
+		}
+	}
+}
+
+func TestPopulateCookies(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	ctx := httptransport.PopulateCookies(context.Background(), r)
+
+	c, err := httptransport.CookieFromContext(ctx, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "abc", c.Value; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if _, err := httptransport.CookieFromContext(ctx, "absent"); err != http.ErrNoCookie {
+		t.Errorf("want %v, have %v", http.ErrNoCookie, err)
+	}
+
+	if _, err := httptransport.CookieFromContext(context.Background(), "session"); err != http.ErrNoCookie {
+		t.Errorf("want %v, have %v", http.ErrNoCookie, err)
+	}
+}
+
+func TestPopulateMethodSemantics(t *testing.T) {
+	for _, test := range []struct {
+		method     string
+		safe       bool
+		idempotent bool
+	}{
+		{http.MethodGet, true, true},
+		{http.MethodHead, true, true},
+		{http.MethodOptions, true, true},
+		{http.MethodPut, false, true},
+		{http.MethodDelete, false, true},
+		{http.MethodPost, false, false},
+		{http.MethodPatch, false, false},
+	} {
+		r := httptest.NewRequest(test.method, "/", nil)
+		ctx := httptransport.PopulateMethodSemantics(context.Background(), r)
+		if want, have := test.safe, httptransport.IsSafeFromContext(ctx); want != have {
+			t.Errorf("%s safe: want %v, have %v", test.method, want, have)
+		}
+		if want, have := test.idempotent, httptransport.IsIdempotentFromContext(ctx); want != have {
+			t.Errorf("%s idempotent: want %v, have %v", test.method, want, have)
+		}
+	}
+
+	if httptransport.IsIdempotentFromContext(context.Background()) {
+		t.Error("want not idempotent when semantics are not populated")
+	}
+}
+
+func TestPopulateIdempotencyKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Idempotency-Key", "charge-1")
+	ctx := httptransport.PopulateIdempotencyKey(context.Background(), r)
+	if want, have := "charge-1", gkit.IdempotencyKeyFromContext(ctx); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestSetRequestHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, r *http.Response) (string, error) {
+			b, err := io.ReadAll(r.Body)
+			return string(b), err
+		},
+		httptransport.ClientBefore[struct{}, string](httptransport.SetRequestHost("api.example.com")),
+	)
+
+	host, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "api.example.com", host; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestRequestDeadlinePropagation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	httptransport.SetRequestDeadline(ctx, r)
+	if r.Header.Get(gkit.TimeoutKey) == "" {
+		t.Fatalf("want %s header, have none", gkit.TimeoutKey)
+	}
+
+	d, ok := gkit.PropagatedTimeoutFromContext(httptransport.PopulateRequestDeadline(context.Background(), r))
+	if !ok || d <= 0 || d > time.Second {
+		t.Errorf("want a propagated timeout within 1s, have %v", d)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	httptransport.SetRequestDeadline(context.Background(), r)
+	if _, ok := gkit.PropagatedTimeoutFromContext(httptransport.PopulateRequestDeadline(context.Background(), r)); ok {
+		t.Error("want no propagated timeout without a deadline")
+	}
+}
+
+func TestRequestBaggagePropagation(t *testing.T) {
+	ctx := gkit.WithBaggageValue(context.Background(), "tenant", "acme")
+	ctx = gkit.WithBaggageValue(ctx, "experiment", "new checkout")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	httptransport.SetRequestBaggage(ctx, r)
+	if want, have := "experiment=new%20checkout,tenant=acme", r.Header.Get(gkit.BaggageKey); want != have {
+		t.Errorf("want header %q, have %q", want, have)
+	}
+
+	r.Header.Add(gkit.BaggageKey, "region=id;ttl=60")
+	b := gkit.BaggageFromContext(httptransport.PopulateRequestBaggage(context.Background(), r))
+	for k, want := range map[string]string{"tenant": "acme", "experiment": "new checkout", "region": "id"} {
+		if have := b[k]; want != have {
+			t.Errorf("%s: want %q, have %q", k, want, have)
+		}
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	httptransport.SetRequestBaggage(context.Background(), r)
+	if b := gkit.BaggageFromContext(httptransport.PopulateRequestBaggage(context.Background(), r)); b != nil {
+		t.Errorf("want no baggage, have %v", b)
+	}
+}
+
+func TestSetRequestNoCache(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	httptransport.SetRequestNoCache(context.Background(), r)
+	if have := r.Header.Get("Cache-Control"); have != "" {
+		t.Errorf("unmarked context: want no Cache-Control, have %q", have)
+	}
+
+	httptransport.SetRequestNoCache(gkit.WithNoCache(context.Background()), r)
+	if want, have := "no-cache", r.Header.Get("Cache-Control"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestSetRequestAuthorization(t *testing.T) {
+	type tokenKey struct{}
+
+	for name, tc := range map[string]struct {
+		f    httptransport.RequestFunc
+		ctx  context.Context
+		want string
+	}{
+		"basic":          {httptransport.SetRequestBasicAuth("alice", "secret"), context.Background(), "Basic YWxpY2U6c2VjcmV0"},
+		"bearer":         {httptransport.SetRequestBearerToken("abc"), context.Background(), "Bearer abc"},
+		"context":        {httptransport.SetRequestTokenFromContext(tokenKey{}), context.WithValue(context.Background(), tokenKey{}, "xyz"), "Bearer xyz"},
+		"context absent": {httptransport.SetRequestTokenFromContext(tokenKey{}), context.Background(), ""},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		tc.f(tc.ctx, r)
+		if have := r.Header.Get("Authorization"); tc.want != have {
+			t.Errorf("%s: want %q, have %q", name, tc.want, have)
+		}
+	}
+}
+
+func TestCombineRequestFuncs(t *testing.T) {
+	type orderKey struct{}
+
+	step := func(name string) httptransport.RequestFunc {
+		return func(ctx context.Context, r *http.Request) context.Context {
+			order, _ := ctx.Value(orderKey{}).(string)
+			return context.WithValue(ctx, orderKey{}, order+name)
+		}
+	}
+	combined := httptransport.CombineRequestFuncs(step("a"), step("b"), httptransport.SetRequestHeader("X-Bundle", "1"))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := combined(context.Background(), r)
+	if want, have := "ab", ctx.Value(orderKey{}); want != have {
+		t.Errorf("want %q, have %v", want, have)
+	}
+	if want, have := "1", r.Header.Get("X-Bundle"); want != have {
+		t.Errorf("X-Bundle: want %q, have %q", want, have)
+	}
+
+	// The combined func is usable on both sides.
+	_ = httptransport.ServerBefore[struct{}, struct{}](combined)
+	_ = httptransport.ClientBefore[struct{}, struct{}](combined)
+}
+
+func TestCombineResponseFuncs(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httptransport.CombineServerResponseFuncs(
+		httptransport.SetContentType("text/plain"),
+		httptransport.SetResponseHeader("X-Foo", "bar"),
+	)(context.Background(), rec)
+	if rec.Header().Get("Content-Type") != "text/plain" || rec.Header().Get("X-Foo") != "bar" {
+		t.Errorf("unexpected headers %v", rec.Header())
+	}
+
+	var calls []string
+	record := func(name string) httptransport.ClientResponseFunc {
+		return func(ctx context.Context, _ *http.Response) context.Context {
+			calls = append(calls, name)
+			return ctx
+		}
+	}
+	httptransport.CombineClientResponseFuncs(record("a"), record("b"))(context.Background(), &http.Response{})
+	if want, have := "[a b]", fmt.Sprint(calls); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
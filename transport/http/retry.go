@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryableFunc decides whether a request should be retried, given the
+// request, response and error of the previous attempt.
+type RetryableFunc func(req *http.Request, resp *http.Response, err error) bool
+
+// BackoffFunc returns how long to wait before the given retry attempt,
+// starting at 1 for the first retry.
+type BackoffFunc func(attempt int) time.Duration
+
+// DefaultRetryable retries on 429 responses and on errors dialing the server,
+// which the server never saw. Other transport errors, except context
+// cancellation, and 502, 503 and 504 responses are retried only if the
+// request may safely be sent twice: its method is idempotent, see
+// IsIdempotentMethod, or it has an Idempotency-Key header. With another header
+// set by ClientIdempotencyKey, use a RetryableFunc of your own.
+func DefaultRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return isDialError(err) || replayableRequest(req)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return replayableRequest(req)
+	default:
+		return false
+	}
+}
+
+// replayableRequest reports whether req may be sent again after the server
+// may have processed it.
+func replayableRequest(req *http.Request) bool {
+	return IsIdempotentMethod(req.Method) || req.Header.Get("Idempotency-Key") != ""
+}
+
+// isDialError reports whether err happened before a connection to the server
+// was made, such as a refused connection or a failed DNS lookup.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// ExponentialBackoff returns a BackoffFunc that doubles base on every attempt,
+// never waiting longer than max.
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base << (attempt - 1)
+		if d <= 0 || d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// RetryTransport is an HTTPClient that retries requests at the transport
+// layer, so every Client sharing it gets retries transparently. Every retry
+// sends a clone of the request, with its body replayed through req.GetBody,
+// so the request given to Do is never modified; requests with a body but no
+// GetBody are never retried.
+type RetryTransport struct {
+	next      HTTPClient
+	max       int
+	backoff   BackoffFunc
+	retryable RetryableFunc
+}
+
+// NewRetryTransport wraps next, retrying a request at most max times. A nil
+// backoff retries immediately and a nil retryable means DefaultRetryable. A
+// Retry-After header on the response takes precedence over backoff.
+func NewRetryTransport(next HTTPClient, max int, backoff BackoffFunc, retryable RetryableFunc) *RetryTransport {
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	return &RetryTransport{next: next, max: max, backoff: backoff, retryable: retryable}
+}
+
+// Do implements HTTPClient.
+func (t *RetryTransport) Do(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.next.Do(attemptReq)
+		if attempt >= t.max || !replayable || !t.retryable(attemptReq, resp, err) {
+			return resp, err
+		}
+
+		wait := time.Duration(0)
+		if t.backoff != nil {
+			wait = t.backoff(attempt + 1)
+		}
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header, time.Now()); ok {
+				wait = d
+			}
+			io.Copy(io.Discard, resp.Body) //nolint:errcheck
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(req, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// bufferBody reads the body of req into memory and sets req.GetBody, so the
+// body can be replayed by RetryTransport. Requests without a body, or already
+// replayable, are left untouched.
+func bufferBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = int64(len(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning early with the context error if the
+// request context is done first.
+func sleepContext(req *http.Request, d time.Duration) error {
+	ctx := req.Context()
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
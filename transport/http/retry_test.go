@@ -0,0 +1,267 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var (
+		attempts int
+		bodies   []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := httptransport.NewRetryTransport(http.DefaultClient, 3, httptransport.ExponentialBackoff(time.Hour, time.Hour), nil)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	req.Header.Set("Idempotency-Key", "charge-1")
+	body := req.Body
+	resp, err := transport.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if req.Body != body {
+		t.Error("want the body of the original request left untouched")
+	}
+
+	if want, have := http.StatusOK, resp.StatusCode; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := 3, attempts; want != have {
+		t.Errorf("attempts: want %d, have %d", want, have)
+	}
+	for i, body := range bodies {
+		if want, have := "payload", body; want != have {
+			t.Errorf("attempt %d: want body %q, have %q", i, want, have)
+		}
+	}
+}
+
+func TestRetryTransportContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	transport := httptransport.NewRetryTransport(http.DefaultClient, 5, httptransport.ExponentialBackoff(time.Hour, time.Hour), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := transport.Do(req); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	var (
+		dialErr  = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		readErr  = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+		response = func(code int) *http.Response { return &http.Response{StatusCode: code} }
+	)
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		key    string
+		resp   *http.Response
+		err    error
+		want   bool
+	}{
+		{"GET 503", http.MethodGet, "", response(http.StatusServiceUnavailable), nil, true},
+		{"PUT 502", http.MethodPut, "", response(http.StatusBadGateway), nil, true},
+		{"POST 503", http.MethodPost, "", response(http.StatusServiceUnavailable), nil, false},
+		{"POST 504 with key", http.MethodPost, "charge-1", response(http.StatusGatewayTimeout), nil, true},
+		{"POST 429", http.MethodPost, "", response(http.StatusTooManyRequests), nil, true},
+		{"GET 500", http.MethodGet, "", response(http.StatusInternalServerError), nil, false},
+		{"POST dial error", http.MethodPost, "", nil, dialErr, true},
+		{"POST read error", http.MethodPost, "", nil, readErr, false},
+		{"POST read error with key", http.MethodPost, "charge-1", nil, readErr, true},
+		{"GET read error", http.MethodGet, "", nil, readErr, true},
+		{"GET canceled", http.MethodGet, "", nil, context.Canceled, false},
+	} {
+		req := httptest.NewRequest(tc.method, "/", nil)
+		if tc.key != "" {
+			req.Header.Set("Idempotency-Key", tc.key)
+		}
+		if have := httptransport.DefaultRetryable(req, tc.resp, tc.err); tc.want != have {
+			t.Errorf("%s: want %v, have %v", tc.name, tc.want, have)
+		}
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var (
+		attempts  int
+		bodies    []string
+		finalized int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL),
+		httptransport.EncodeJSONRequest[string],
+		func(_ context.Context, r *http.Response) (int, error) { return r.StatusCode, nil },
+		httptransport.ClientRetry[string, int](5, nil, func(_ *http.Request, resp *http.Response, err error) bool {
+			return err == nil && resp.StatusCode == http.StatusBadGateway
+		}),
+		httptransport.ClientFinalizer[string, int](func(context.Context, error) { finalized++ }),
+	)
+
+	code, err := client.Endpoint()(context.Background(), "payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusOK, code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := 3, attempts; want != have {
+		t.Errorf("attempts: want %d, have %d", want, have)
+	}
+	for i, body := range bodies {
+		if want, have := `"payload"`, strings.TrimSpace(body); want != have {
+			t.Errorf("attempt %d: want body %s, have %s", i, want, have)
+		}
+	}
+	if want, have := 1, finalized; want != have {
+		t.Errorf("finalizer: want %d call, have %d", want, have)
+	}
+}
+
+func TestClientRetryContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse(server.URL),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientRetry[struct{}, struct{}](10, httptransport.ExponentialBackoff(time.Hour, time.Hour), nil),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.Endpoint()(ctx, struct{}{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retries outlived the context by %v", elapsed)
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	var (
+		attempts int
+		keys     []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		attempts++
+		if attempts%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var generated int
+	client := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL),
+		httptransport.EncodeJSONRequest[string],
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientRetry[string, struct{}](3, nil, nil),
+		httptransport.ClientIdempotencyKey[string, struct{}](func(context.Context, string) string {
+			generated++
+			return "key-" + strconv.Itoa(generated)
+		}, ""),
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Endpoint()(context.Background(), "charge"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want, have := "[key-1 key-1 key-2 key-2]", fmt.Sprint(keys); want != have {
+		t.Errorf("want keys %s, have %s", want, have)
+	}
+}
+
+func TestClientIdempotencyKeyIgnoresContext(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+	}))
+	defer server.Close()
+
+	var generated int
+	client := httptransport.NewClient(
+		http.MethodPost,
+		mustParse(server.URL),
+		httptransport.EncodeJSONRequest[string],
+		func(context.Context, *http.Response) (struct{}, error) { return struct{}{}, nil },
+		httptransport.ClientIdempotencyKey[string, struct{}](func(context.Context, string) string {
+			generated++
+			return "key-" + strconv.Itoa(generated)
+		}, ""),
+	)
+
+	// A server forwarding the key it received must not send it downstream.
+	ctx := gkit.WithIdempotencyKey(context.Background(), "upstream")
+	for i := 0; i < 2; i++ {
+		if _, err := client.Endpoint()(ctx, "charge"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(keys) != 2 || keys[0] == keys[1] || keys[0] == "upstream" {
+		t.Errorf("want two distinct fresh keys, have %v", keys)
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type runConfig struct {
+	h2c             bool
+	shutdownTimeout time.Duration
+}
+
+// RunOption sets an optional parameter for RunServer and Serve.
+type RunOption gkit.Option[*runConfig]
+
+// RunH2C enables HTTP/2 over cleartext TCP (h2c), for service-to-service
+// calls inside a mesh terminating TLS. HTTP/1.1 requests are still served.
+func RunH2C() RunOption {
+	return func(c *runConfig) { c.h2c = true }
+}
+
+// RunShutdownTimeout sets how long in-flight requests are given to complete
+// once the context is done. Defaults to 10 seconds.
+func RunShutdownTimeout(d time.Duration) RunOption {
+	return func(c *runConfig) { c.shutdownTimeout = d }
+}
+
+// RunServer listens on the TCP address addr and serves h until ctx is done.
+// See Serve.
+func RunServer(ctx context.Context, addr string, h http.Handler, options ...RunOption) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, ln, h, options...)
+}
+
+// Serve serves h on ln until ctx is done, then shuts down gracefully, waiting
+// for in-flight requests up to the shutdown timeout. It returns nil after a
+// graceful shutdown, and the serving or shutdown error otherwise.
+func Serve(ctx context.Context, ln net.Listener, h http.Handler, options ...RunOption) error {
+	cfg := &runConfig{shutdownTimeout: 10 * time.Second}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	if cfg.h2c {
+		h = h2c.NewHandler(h, &http2.Server{})
+	}
+
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return context.WithoutCancel(ctx) },
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+	"golang.org/x/net/http2"
+)
+
+// newH2CClient returns a client speaking HTTP/2 in cleartext.
+func newH2CClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+}
+
+func TestServeH2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finalized := make(chan int, 1)
+	handler := httptransport.NewServer(
+		func(context.Context, struct{}) (string, error) { return "multiplexed", nil },
+		gkit.NopEncoderDecoder[*http.Request, struct{}],
+		httptransport.EncodeJSONResponse[string],
+		httptransport.ServerFinalizer[struct{}, string](func(_ context.Context, code int, _ *http.Request) {
+			finalized <- code
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- httptransport.Serve(ctx, ln, handler, httptransport.RunH2C()) }()
+
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse("http://"+ln.Addr().String()),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, r *http.Response) (int, error) {
+			io.Copy(io.Discard, r.Body)
+			return r.ProtoMajor, nil
+		},
+		httptransport.SetClient[struct{}, int](newH2CClient()),
+	)
+
+	proto, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, proto; want != have {
+		t.Errorf("ProtoMajor: want %d, have %d", want, have)
+	}
+
+	select {
+	case code := <-finalized:
+		if want, have := http.StatusOK, code; want != have {
+			t.Errorf("finalizer code: want %d, have %d", want, have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for finalizer")
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("want graceful shutdown, have %v", err)
+	}
+}
+
+func TestServeH2CBufferedStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first ")) //nolint:errcheck
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second")) //nolint:errcheck
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- httptransport.Serve(ctx, ln, handler, httptransport.RunH2C()) }()
+
+	type stream struct {
+		proto int
+		body  io.ReadCloser
+	}
+	client := httptransport.NewClient(
+		http.MethodGet,
+		mustParse("http://"+ln.Addr().String()),
+		func(context.Context, *http.Request, struct{}) error { return nil },
+		func(_ context.Context, r *http.Response) (stream, error) { return stream{r.ProtoMajor, r.Body}, nil },
+		httptransport.SetClient[struct{}, stream](newH2CClient()),
+		httptransport.BufferedStream[struct{}, stream](true),
+	)
+
+	res, err := client.Endpoint()(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, res.proto; want != have {
+		t.Errorf("ProtoMajor: want %d, have %d", want, have)
+	}
+
+	// The endpoint returned before the server finished the response, so the
+	// rest of the stream must still be readable.
+	close(release)
+	b, err := io.ReadAll(res.body)
+	if err != nil {
+		t.Fatalf("reading the stream after the endpoint returned: %v", err)
+	}
+	if want, have := "first second", string(b); want != have {
+		t.Errorf("body: want %q, have %q", want, have)
+	}
+	if err := res.body.Close(); err != nil {
+		t.Error(err)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("want graceful shutdown, have %v", err)
+	}
+}
@@ -1,9 +1,16 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 
 	gkit "github.com/bobobox-id/gkit/core"
 )
@@ -18,6 +25,10 @@ type Server[Req, Res any] struct {
 	errorEncoder gkit.ErrorEncoder[http.ResponseWriter]
 	finalizer    []ServerFinalizerFunc
 	errorHandler gkit.ErrorHandler
+	expect       func(*http.Request) (bool, int)
+	skipClosed   bool
+	cors         *CORSConfig
+	requestID    func() string
 }
 
 // NewServer constructs a new HTTP server, which implements http.Handler and wraps
@@ -89,12 +100,66 @@ func ServerFinalizer[Req, Res any](f ...ServerFinalizerFunc) ServerOption[Req, R
 	return func(s *Server[Req, Res]) { s.finalizer = append(s.finalizer, f...) }
 }
 
+// ServerExpectContinue sets a predicate consulted for requests carrying an
+// "Expect: 100-continue" header, before anything else is done with the
+// request. If it returns true, the request is processed normally and the
+// standard library sends 100 Continue once the body is first read. If it
+// returns false, the returned status code is written and the body is never
+// read, so the client doesn't upload it.
+func ServerExpectContinue[Req, Res any](expect func(r *http.Request) (ok bool, code int)) ServerOption[Req, Res] {
+	return func(s *Server[Req, Res]) { s.expect = expect }
+}
+
+// StatusClientClosedRequest is the non-standard status code, popularized by
+// nginx, recorded when the client closed the connection before the response
+// was written.
+const StatusClientClosedRequest = 499
+
+// ServerSkipClientClosed sets whether errors caused by the client closing the
+// connection, as detected by IsClientClosed, are dropped: the error handler
+// and error encoder aren't called, since nobody is listening, and finalizers
+// see StatusClientClosedRequest as the status code instead of the one the
+// error would've been encoded with. By default, such errors are handled like
+// any other.
+func ServerSkipClientClosed[Req, Res any](skip bool) ServerOption[Req, Res] {
+	return func(s *Server[Req, Res]) { s.skipClosed = skip }
+}
+
+// IsClientClosed reports whether err was caused by the client of r closing the
+// connection, that is, err is context.Canceled and so is the context of r. A
+// timeout, where the error is context.DeadlineExceeded, isn't a closed client.
+func IsClientClosed(r *http.Request, err error) bool {
+	return errors.Is(err, context.Canceled) && errors.Is(r.Context().Err(), context.Canceled)
+}
+
+// GenerateRequestID makes the server set the X-Request-Id header of requests
+// lacking one to an ID returned by gen, before the before funcs run, so
+// PopulateRequestContext always stores one. The ID, generated or sent by the
+// client, is echoed in the X-Request-Id header of the response. A client
+// supplied ID is never replaced. If gen is nil, random UUIDs are generated.
+func GenerateRequestID[Req, Res any](gen func() string) ServerOption[Req, Res] {
+	if gen == nil {
+		gen = newUUID
+	}
+	return func(s *Server[Req, Res]) { s.requestID = gen }
+}
+
+// newUUID returns a random, version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:]) //nolint:errcheck
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
 // ServeHTTP implements http.Handler.
 func (s Server[Req, Res]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	var iw *interceptingWriter
 	if len(s.finalizer) > 0 {
-		iw := &interceptingWriter{w, http.StatusOK, 0}
+		iw = &interceptingWriter{w, http.StatusOK, 0}
 		defer func() {
 			ctx = context.WithValue(ctx, ContextKeyResponseHeaders, iw.Header())
 			ctx = context.WithValue(ctx, ContextKeyResponseSize, iw.written)
@@ -105,21 +170,39 @@ func (s Server[Req, Res]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w = iw.reimplementInterfaces()
 	}
 
+	if s.expect != nil && strings.EqualFold(r.Header.Get("Expect"), "100-continue") {
+		if ok, code := s.expect(r); !ok {
+			w.WriteHeader(code)
+			return
+		}
+	}
+
+	if s.requestID != nil {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" {
+			id = s.requestID()
+			r.Header.Set("X-Request-Id", id)
+		}
+		w.Header().Set("X-Request-Id", id)
+	}
+
+	if s.cors != nil && s.cors.handle(w, r) {
+		return
+	}
+
 	for _, f := range s.before {
 		ctx = f(ctx, r)
 	}
 
 	request, err := s.dec(ctx, r)
 	if err != nil {
-		s.errorHandler.Handle(ctx, err)
-		s.errorEncoder(ctx, w, err)
+		s.handleError(ctx, w, iw, r, err)
 		return
 	}
 
 	response, err := s.e(ctx, request)
 	if err != nil {
-		s.errorHandler.Handle(ctx, err)
-		s.errorEncoder(ctx, w, err)
+		s.handleError(ctx, w, iw, r, err)
 		return
 	}
 
@@ -127,13 +210,61 @@ func (s Server[Req, Res]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ctx = f(ctx, w, err)
 	}
 
-	if err := s.enc(ctx, w, response); err != nil {
-		s.errorHandler.Handle(ctx, err)
-		s.errorEncoder(ctx, w, err)
+	if err := s.encode(ctx, w, response); err != nil {
+		s.handleError(ctx, w, iw, r, err)
 		return
 	}
 }
 
+// handleError passes err to the error handler and error encoder, unless the
+// client closed the connection and skipClosed is set.
+func (s Server[Req, Res]) handleError(ctx context.Context, w http.ResponseWriter, iw *interceptingWriter, r *http.Request, err error) {
+	if s.skipClosed && IsClientClosed(r, err) {
+		if iw != nil {
+			iw.code = StatusClientClosedRequest
+		}
+		return
+	}
+
+	s.errorHandler.Handle(ctx, err)
+	s.errorEncoder(ctx, w, err)
+}
+
+// encode calls the response encoder, turning a panic into an *EncodeError so
+// the client gets a 500 rather than a dropped connection, and finalizers
+// still run. http.ErrAbortHandler is re-panicked, as it's meant to abort the
+// response.
+func (s Server[Req, Res]) encode(ctx context.Context, w http.ResponseWriter, response Res) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			err = &EncodeError{Panic: v}
+		}
+	}()
+
+	return s.enc(ctx, w, response)
+}
+
+// EncodeError is the error passed to the error encoder and handler of a
+// Server when its response encoder panics, e.g. marshaling a value whose
+// MarshalJSON panics. If the encoder had already written the status line, the
+// client receives a truncated response instead of a 500.
+type EncodeError struct {
+	Panic any
+}
+
+// Error implements the error interface.
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("http: response encoder panicked: %v", e.Panic)
+}
+
+// StatusCode implements StatusCoder.
+func (e *EncodeError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
 // ErrorEncoder is responsible for encoding an error to the ResponseWriter.
 // Users are encouraged to use custom ErrorEncoders to encode HTTP errors to
 // their clients, and will likely want to pass and check for their own error
@@ -148,39 +279,67 @@ type ErrorEncoder func(ctx context.Context, err error, w http.ResponseWriter)
 type ServerFinalizerFunc func(ctx context.Context, code int, r *http.Request)
 
 // DecodeJSONRequest is a DecodeRequestFunc that deserialize JSON to domain object.
-func DecodeJSONRequest[Req any](_ context.Context, r *http.Request) (Req, error) {
-	var req Req
-	defer r.Body.Close()
+func DecodeJSONRequest[Req any](ctx context.Context, r *http.Request) (Req, error) {
+	return DecodeJSONRequestWith[Req]()(ctx, r)
+}
 
-	err := json.NewDecoder(r.Body).Decode(&req)
-	if err != nil {
-		return req, err
-	}
+// DecodeJSONRequestWith returns a DecodeRequestFunc like DecodeJSONRequest
+// whose json.Decoder is configured with options, e.g. gkit.UseNumber to keep
+// numbers decoded into any fields as json.Number.
+func DecodeJSONRequestWith[Req any](options ...gkit.JSONDecoderOption) gkit.EncodeDecodeFunc[*http.Request, Req] {
+	return func(_ context.Context, r *http.Request) (Req, error) {
+		var req Req
+		defer r.Body.Close()
+
+		dec := json.NewDecoder(r.Body)
+		for _, option := range options {
+			option(dec)
+		}
+
+		err := dec.Decode(&req)
+		if err != nil {
+			return req, err
+		}
 
-	return req, nil
+		return req, nil
+	}
 }
 
 // EncodeJSONResponse is a EncodeResponseFunc that serializes the response as a
 // JSON object to the ResponseWriter. Many JSON-over-HTTP services can use it as
-// a sensible default. TODO: If the response implements Headerer, the provided headers
+// a sensible default. If the response implements Headerer, the provided headers
 // will be applied to the response. If the response implements StatusCoder, the
-// provided StatusCode will be used instead of 200.
+// provided StatusCode will be used instead of 200. A nil response, such as a
+// nil pointer, is written as null with a 200, even if its type implements
+// either interface.
+//
+// The response is marshaled to a buffer first, so Content-Length is set and a
+// marshaling error is reported before anything is written. Use
+// EncodeJSONResponseStream for large responses that shouldn't be buffered.
 func EncodeJSONResponse[Res any](_ context.Context, w http.ResponseWriter, response Res) error {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	code := statusCode(response)
 
-	if headerer, ok := any(response).(Headerer); ok {
-		for k, values := range headerer.Headers() {
-			for _, v := range values {
-				w.Header().Add(k, v)
-			}
+	var b bytes.Buffer
+	if code != http.StatusNoContent {
+		if err := json.NewEncoder(&b).Encode(response); err != nil {
+			return err
 		}
+		w.Header().Set("Content-Length", strconv.Itoa(b.Len()))
 	}
 
-	code := http.StatusOK
-	if sc, ok := any(response).(StatusCoder); ok {
-		code = sc.StatusCode()
-	}
+	setJSONHeaders(w, response)
+	w.WriteHeader(code)
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
 
+// EncodeJSONResponseStream is like EncodeJSONResponse, but encodes the
+// response directly to the ResponseWriter. No Content-Length is set, and an
+// error occurring midway leaves the client with a truncated body.
+func EncodeJSONResponseStream[Res any](_ context.Context, w http.ResponseWriter, response Res) error {
+	code := statusCode(response)
+	setJSONHeaders(w, response)
 	w.WriteHeader(code)
 
 	if code == http.StatusNoContent {
@@ -190,25 +349,67 @@ func EncodeJSONResponse[Res any](_ context.Context, w http.ResponseWriter, respo
 	return json.NewEncoder(w).Encode(response)
 }
 
+// setJSONHeaders sets the Content-Type header and the headers of a Headerer
+// response.
+func setJSONHeaders(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	setResponseHeaders(w, response)
+}
+
+// setResponseHeaders adds the headers of a Headerer response.
+func setResponseHeaders(w http.ResponseWriter, response any) {
+	if headerer, ok := response.(Headerer); ok && !derefsNil(response, "Headers") {
+		for k, values := range headerer.Headers() {
+			for _, v := range values {
+				w.Header().Add(k, v)
+			}
+		}
+	}
+}
+
+// statusCode returns the status code of a StatusCoder response, or 200.
+func statusCode(response any) int {
+	if sc, ok := response.(StatusCoder); ok && !derefsNil(response, "StatusCode") {
+		return sc.StatusCode()
+	}
+	return http.StatusOK
+}
+
+// derefsNil reports whether response is a nil pointer whose method, named
+// method, has a value receiver, so calling it would dereference nil and
+// panic. Methods with pointer receivers may handle a nil receiver, and so
+// may methods of nil maps and slices, so they're called.
+func derefsNil(response any, method string) bool {
+	v := reflect.ValueOf(response)
+	if v.Kind() != reflect.Pointer || !v.IsNil() {
+		return false
+	}
+	_, ok := v.Type().Elem().MethodByName(method)
+	return ok
+}
+
 // DefaultErrorEncoder writes the error to the ResponseWriter, by default a
-// content type of text/plain, a body of the plain text of the error, and a
-// status code of 500. If the error implements Headerer, the provided headers
+// content type of application/json, a body of the form {"error": "..."}, and
+// a status code of 500. If the error implements Headerer, the provided headers
 // will be applied to the response. If the error implements json.Marshaler, and
-// the marshaling succeeds, a content type of application/json and the JSON
-// encoded form of the error will be used. If the error implements StatusCoder,
-// the provided StatusCode will be used instead of 500.
+// the marshaling succeeds, the JSON encoded form of the error will be used as
+// the body instead. If the error implements StatusCoder, the provided
+// StatusCode will be used instead of 500. The interfaces are looked up in the
+// chain of err with errors.As, so they're still honored once err is wrapped,
+// e.g. by gkit.WrapErrorContext.
 func DefaultErrorEncoder(_ context.Context, w http.ResponseWriter, err error) {
-	contentType, body := "text/plain; charset=utf-8", []byte(err.Error())
-
-	if marshaler, ok := err.(json.Marshaler); ok {
+	body, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	if marshaler, ok := asError[json.Marshaler](err); ok {
 		if jsonBody, marshalErr := marshaler.MarshalJSON(); marshalErr == nil {
-			contentType, body = "application/json; charset=utf-8", jsonBody
+			body = jsonBody
 		}
 	}
 
-	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-	if headerer, ok := err.(Headerer); ok {
+	if headerer, ok := asError[Headerer](err); ok {
 		for k, values := range headerer.Headers() {
 			for _, v := range values {
 				w.Header().Add(k, v)
@@ -216,25 +417,42 @@ func DefaultErrorEncoder(_ context.Context, w http.ResponseWriter, err error) {
 		}
 	}
 
-	code := http.StatusInternalServerError
-	if sc, ok := err.(StatusCoder); ok {
-		code = sc.StatusCode()
-	}
-
-	w.WriteHeader(code)
+	w.WriteHeader(errorStatusCode(err))
 	w.Write(body) //nolint:errcheck
 }
 
+// asError returns the first error in the chain of err implementing T, as
+// found by errors.As.
+func asError[T any](err error) (T, bool) {
+	var target T
+	ok := errors.As(err, &target)
+	return target, ok
+}
+
+// errorStatusCode returns the status code of the first StatusCoder in the
+// chain of err, or 500.
+func errorStatusCode(err error) int {
+	if sc, ok := asError[StatusCoder](err); ok {
+		return sc.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
 // StatusCoder is checked by DefaultErrorEncoder. If an error value implements
 // StatusCoder, the StatusCode will be used when encoding the error. By default,
-// StatusInternalServerError (500) is used.
+// StatusInternalServerError (500) is used. EncodeJSONResponse and
+// EncodeJSONResponseStream check it on responses, defaulting to 200.
 type StatusCoder interface {
 	StatusCode() int
 }
 
 // Headerer is checked by DefaultErrorEncoder. If an error value implements
 // Headerer, the provided headers will be applied to the response writer, after
-// the Content-Type is set.
+// the Content-Type is set. EncodeJSONResponse and EncodeJSONResponseStream
+// check it on responses as well.
+//
+// Custom encoders honoring Headerer and StatusCoder must set the headers
+// before calling WriteHeader: headers set afterwards are silently dropped.
 type Headerer interface {
 	Headers() http.Header
 }
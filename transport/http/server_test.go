@@ -2,10 +2,12 @@ package http_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -58,6 +60,38 @@ func TestServerBadEncode(t *testing.T) {
 	}
 }
 
+func TestServerPanickingEncode(t *testing.T) {
+	var (
+		encodeErr *httptransport.EncodeError
+		finalized bool
+	)
+	handler := httptransport.NewServer(
+		func(context.Context, any) (any, error) { return emptyStruct{}, nil },
+		func(context.Context, *http.Request) (any, error) { return emptyStruct{}, nil },
+		func(context.Context, http.ResponseWriter, any) error { panic("not marshalable") },
+		httptransport.ServerErrorHandler[any, any](gkit.ErrorHandlerFunc(func(_ context.Context, err error) {
+			errors.As(err, &encodeErr)
+		})),
+		httptransport.ServerFinalizer[any, any](func(context.Context, int, *http.Request) { finalized = true }),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusInternalServerError, resp.StatusCode; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if encodeErr == nil || encodeErr.Panic != "not marshalable" {
+		t.Errorf("want *EncodeError carrying the panic, have %v", encodeErr)
+	}
+	if !finalized {
+		t.Error("finalizer was not run")
+	}
+}
+
 func TestServerErrorEncoder(t *testing.T) {
 	errTeapot := errors.New("teapot")
 	code := func(err error) int {
@@ -80,6 +114,58 @@ func TestServerErrorEncoder(t *testing.T) {
 	}
 }
 
+func TestServerErrorEncoderStages(t *testing.T) {
+	errNotFound := errors.New("not found")
+	encoder := func(ctx context.Context, w http.ResponseWriter, err error) {
+		if errors.Is(err, errNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		httptransport.DefaultErrorEncoder(ctx, w, err)
+	}
+
+	for _, stage := range []string{"decode", "endpoint", "encode"} {
+		fail := func(s string) error {
+			if s == stage {
+				return errNotFound
+			}
+			return nil
+		}
+		handler := httptransport.NewServer(
+			func(context.Context, emptyStruct) (emptyStruct, error) { return emptyStruct{}, fail("endpoint") },
+			func(context.Context, *http.Request) (emptyStruct, error) { return emptyStruct{}, fail("decode") },
+			func(context.Context, http.ResponseWriter, emptyStruct) error { return fail("encode") },
+			httptransport.ServerErrorEncoder[emptyStruct, emptyStruct](encoder),
+		)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if want, have := http.StatusNotFound, rec.Code; want != have {
+			t.Errorf("%s: want %d, have %d", stage, want, have)
+		}
+	}
+}
+
+func TestDefaultErrorEncoder(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httptransport.DefaultErrorEncoder(context.Background(), rec, errors.New("boom"))
+	if want, have := http.StatusInternalServerError, rec.Code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "application/json; charset=utf-8", rec.Header().Get("Content-Type"); want != have {
+		t.Errorf("Content-Type: want %q, have %q", want, have)
+	}
+	if want, have := `{"error":"boom"}`, rec.Body.String(); want != have {
+		t.Errorf("body: want %s, have %s", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	httptransport.DefaultErrorEncoder(context.Background(), rec, &httptransport.BodyReadError{Err: errors.New("reset")})
+	if want, have := http.StatusBadGateway, rec.Code; want != have {
+		t.Errorf("StatusCoder: want %d, have %d", want, have)
+	}
+}
+
 func TestServerErrorHandler(t *testing.T) {
 	errTeapot := errors.New("teapot")
 	msgChan := make(chan string, 1)
@@ -345,8 +431,58 @@ func TestAddMultipleHeadersErrorEncoder(t *testing.T) {
 			t.Errorf("Header: unexpected header %s: %v", k, expect[k])
 		}
 	}
-	if b, _ := io.ReadAll(resp.Body); errStr != string(b) {
-		t.Errorf("ErrorEncoder: got: %q, expected: %q", b, errStr)
+	want := `{"error":"` + errStr + `"}`
+	if b, _ := io.ReadAll(resp.Body); want != string(b) {
+		t.Errorf("ErrorEncoder: got: %q, expected: %q", b, want)
+	}
+}
+
+func TestEncodeJSONResponseContentLength(t *testing.T) {
+	for name, enc := range map[string]httptransport.EncodeResponseFunc[enhancedResponse]{
+		"buffered":  httptransport.EncodeJSONResponse[enhancedResponse],
+		"streaming": httptransport.EncodeJSONResponseStream[enhancedResponse],
+	} {
+		w := httptest.NewRecorder()
+		if err := enc(context.Background(), w, enhancedResponse{Foo: "bar"}); err != nil {
+			t.Fatal(err)
+		}
+
+		want := ""
+		if name == "buffered" {
+			want = strconv.Itoa(w.Body.Len())
+		}
+		if have := w.Header().Get("Content-Length"); want != have {
+			t.Errorf("%s: Content-Length: want %q, have %q", name, want, have)
+		}
+		if want, have := `{"foo":"bar"}`, strings.TrimSpace(w.Body.String()); want != have {
+			t.Errorf("%s: body: want %s, have %s", name, want, have)
+		}
+		if want, have := http.StatusPaymentRequired, w.Code; want != have {
+			t.Errorf("%s: StatusCode: want %d, have %d", name, want, have)
+		}
+	}
+}
+
+type panickingResponse struct{}
+
+func (panickingResponse) MarshalJSON() ([]byte, error) { panic("not marshalable") }
+
+func TestEncodeJSONResponsePanic(t *testing.T) {
+	handler := httptransport.NewServer(
+		func(context.Context, any) (panickingResponse, error) { return panickingResponse{}, nil },
+		func(context.Context, *http.Request) (any, error) { return emptyStruct{}, nil },
+		httptransport.EncodeJSONResponse[panickingResponse],
+		httptransport.ServerErrorHandler[any, panickingResponse](gkit.ErrorHandlerFunc(func(context.Context, error) {})),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusInternalServerError, resp.StatusCode; want != have {
+		t.Errorf("want %d, have %d", want, have)
 	}
 }
 
@@ -459,3 +595,285 @@ func testServer(t *testing.T) (step func(), resp <-chan *http.Response) {
 	}()
 	return func() { stepch <- true }, response
 }
+
+func TestServerExpectContinue(t *testing.T) {
+	var called bool
+	handler := httptransport.NewServer(
+		func(context.Context, emptyStruct) (emptyStruct, error) { called = true; return emptyStruct{}, nil },
+		func(_ context.Context, r *http.Request) (emptyStruct, error) {
+			_, err := io.Copy(io.Discard, r.Body)
+			return emptyStruct{}, err
+		},
+		func(context.Context, http.ResponseWriter, emptyStruct) error { return nil },
+		httptransport.ServerExpectContinue[emptyStruct, emptyStruct](func(r *http.Request) (bool, int) {
+			if r.ContentLength > 1024 {
+				return false, http.StatusRequestEntityTooLarge
+			}
+			return true, 0
+		}),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: time.Second}}
+	for _, test := range []struct {
+		size   int
+		code   int
+		called bool
+	}{
+		{size: 16, code: http.StatusOK, called: true},
+		{size: 4096, code: http.StatusRequestEntityTooLarge, called: false},
+	} {
+		called = false
+		req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(strings.Repeat("x", test.size)))
+		req.Header.Set("Expect", "100-continue")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if want, have := test.code, resp.StatusCode; want != have {
+			t.Errorf("size %d: StatusCode: want %d, have %d", test.size, want, have)
+		}
+		if want, have := test.called, called; want != have {
+			t.Errorf("size %d: endpoint called: want %v, have %v", test.size, want, have)
+		}
+	}
+}
+
+func TestDecodeJSONRequestWithUseNumber(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount": 12345678901234567890}`))
+
+	request, err := httptransport.DecodeJSONRequestWith[map[string]any](gkit.UseNumber)(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, ok := request["amount"].(json.Number)
+	if !ok {
+		t.Fatalf("want json.Number, have %T", request["amount"])
+	}
+	if want, have := "12345678901234567890", n.String(); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestServerSkipClientClosed(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cancel   func(context.Context) (context.Context, context.CancelFunc)
+		wantCode int
+		wantBody bool
+	}{
+		"client closed": {context.WithCancel, httptransport.StatusClientClosedRequest, false},
+		"timeout": {func(ctx context.Context) (context.Context, context.CancelFunc) {
+			return context.WithTimeout(ctx, 0)
+		}, http.StatusInternalServerError, true},
+	} {
+		var (
+			handled   int
+			finalized int
+		)
+		handler := httptransport.NewServer(
+			func(ctx context.Context, _ emptyStruct) (emptyStruct, error) { return emptyStruct{}, ctx.Err() },
+			func(context.Context, *http.Request) (emptyStruct, error) { return emptyStruct{}, nil },
+			func(context.Context, http.ResponseWriter, emptyStruct) error { return nil },
+			httptransport.ServerSkipClientClosed[emptyStruct, emptyStruct](true),
+			httptransport.ServerErrorHandler[emptyStruct, emptyStruct](gkit.ErrorHandlerFunc(func(context.Context, error) { handled++ })),
+			httptransport.ServerFinalizer[emptyStruct, emptyStruct](func(_ context.Context, code int, _ *http.Request) { finalized = code }),
+		)
+
+		ctx, cancel := tc.cancel(context.Background())
+		cancel()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+		if want, have := tc.wantCode, finalized; want != have {
+			t.Errorf("%s: finalizer code: want %d, have %d", name, want, have)
+		}
+		if want, have := tc.wantBody, rec.Body.Len() > 0; want != have {
+			t.Errorf("%s: want response written %v, have %v", name, want, have)
+		}
+		if want, have := tc.wantBody, handled > 0; want != have {
+			t.Errorf("%s: want error handled %v, have %v", name, want, have)
+		}
+	}
+}
+
+type partialResponse struct {
+	Hits    int `json:"hits"`
+	partial bool
+}
+
+func (r partialResponse) Partial() bool { return r.partial }
+
+func TestEncodePartial(t *testing.T) {
+	enc := httptransport.EncodePartial(httptransport.EncodeJSONResponse[partialResponse])
+
+	for _, partial := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		if err := enc(context.Background(), rec, partialResponse{Hits: 1, partial: partial}); err != nil {
+			t.Fatal(err)
+		}
+
+		want := ""
+		if partial {
+			want = "true"
+		}
+		if have := rec.Header().Get(httptransport.PartialHeader); want != have {
+			t.Errorf("partial %v: want header %q, have %q", partial, want, have)
+		}
+		if want, have := http.StatusOK, rec.Code; want != have {
+			t.Errorf("partial %v: StatusCode: want %d, have %d", partial, want, have)
+		}
+	}
+}
+
+type createdResponse struct {
+	ID string `json:"id"`
+}
+
+func (r createdResponse) StatusCode() int { return http.StatusCreated }
+
+func (r createdResponse) Headers() http.Header {
+	return http.Header{"Location": []string{"/users/" + r.ID}}
+}
+
+func TestEncodeJSONResponseStatusCoderHeaderer(t *testing.T) {
+	for name, enc := range map[string]httptransport.EncodeResponseFunc[createdResponse]{
+		"buffered": httptransport.EncodeJSONResponse[createdResponse],
+		"stream":   httptransport.EncodeJSONResponseStream[createdResponse],
+	} {
+		rec := httptest.NewRecorder()
+		if err := enc(context.Background(), rec, createdResponse{ID: "42"}); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := http.StatusCreated, rec.Code; want != have {
+			t.Errorf("%s: StatusCode: want %d, have %d", name, want, have)
+		}
+		if want, have := "/users/42", rec.Header().Get("Location"); want != have {
+			t.Errorf("%s: Location: want %q, have %q", name, want, have)
+		}
+		if want, have := `{"id":"42"}`, strings.TrimSpace(rec.Body.String()); want != have {
+			t.Errorf("%s: body: want %s, have %s", name, want, have)
+		}
+	}
+}
+
+func TestEncodeJSONResponseNil(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := httptransport.EncodeJSONResponse[*createdResponse](context.Background(), rec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusOK, rec.Code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+	if want, have := "null", strings.TrimSpace(rec.Body.String()); want != have {
+		t.Errorf("body: want %s, have %s", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := httptransport.EncodeJSONResponse[any](context.Background(), rec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "null", strings.TrimSpace(rec.Body.String()); want != have {
+		t.Errorf("nil interface: want %s, have %s", want, have)
+	}
+}
+
+func TestGenerateRequestID(t *testing.T) {
+	var seen string
+	handler := httptransport.NewServer(
+		func(ctx context.Context, _ emptyStruct) (emptyStruct, error) {
+			seen, _ = ctx.Value(httptransport.ContextKeyRequestXRequestID).(string)
+			return emptyStruct{}, nil
+		},
+		func(context.Context, *http.Request) (emptyStruct, error) { return emptyStruct{}, nil },
+		httptransport.EncodeJSONResponse[emptyStruct],
+		httptransport.ServerBefore[emptyStruct, emptyStruct](httptransport.PopulateRequestContext),
+		httptransport.GenerateRequestID[emptyStruct, emptyStruct](nil),
+	)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	generated := rec.Header().Get("X-Request-Id")
+	if len(generated) != 36 || seen != generated {
+		t.Errorf("generated: want a UUID in the context and response, have %q and %q", seen, generated)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "client-id")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if want, have := "client-id", rec.Header().Get("X-Request-Id"); want != have || seen != want {
+		t.Errorf("client supplied: want %q, have %q in the response and %q in the context", want, have, seen)
+	}
+}
+
+type page []string
+
+func (page) StatusCode() int { return http.StatusPartialContent }
+
+type nilSafeResponse struct{}
+
+func (r *nilSafeResponse) StatusCode() int {
+	if r == nil {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+func TestEncodeJSONResponseNilStatusCoder(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := httptransport.EncodeJSONResponse[page](context.Background(), rec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusPartialContent, rec.Code; want != have {
+		t.Errorf("nil slice: want %d, have %d", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	if err := httptransport.EncodeJSONResponse[*nilSafeResponse](context.Background(), rec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusAccepted, rec.Code; want != have {
+		t.Errorf("nil pointer receiver: want %d, have %d", want, have)
+	}
+}
+
+// invalidInputError is a 4xx error with headers and a JSON form.
+type invalidInputError struct{}
+
+func (invalidInputError) Error() string        { return "invalid input" }
+func (invalidInputError) StatusCode() int      { return http.StatusBadRequest }
+func (invalidInputError) Headers() http.Header { return http.Header{"X-Invalid": []string{"name"}} }
+func (invalidInputError) MarshalJSON() ([]byte, error) {
+	return []byte(`{"field":"name"}`), nil
+}
+
+func TestErrorEncodersWrappedError(t *testing.T) {
+	for name, enc := range map[string]gkit.ErrorEncoder[http.ResponseWriter]{
+		"default":  httptransport.DefaultErrorEncoder,
+		"envelope": httptransport.EnvelopeError(httptransport.Envelope{}),
+	} {
+		handler := httptransport.NewServer(
+			gkit.WrapErrorContext[struct{}, struct{}]()(func(context.Context, struct{}) (struct{}, error) {
+				return struct{}{}, invalidInputError{}
+			}),
+			gkit.NopEncoderDecoder[*http.Request, struct{}],
+			httptransport.EncodeJSONResponse[struct{}],
+			httptransport.ServerErrorEncoder[struct{}, struct{}](enc),
+		)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if want, have := http.StatusBadRequest, rec.Code; want != have {
+			t.Errorf("%s: StatusCode: want %d, have %d", name, want, have)
+		}
+		if want, have := "name", rec.Header().Get("X-Invalid"); want != have {
+			t.Errorf("%s: X-Invalid: want %q, have %q", name, want, have)
+		}
+		if want, have := `{"field":"name"}`, rec.Body.String(); !strings.Contains(have, want) {
+			t.Errorf("%s: body: want %s in it, have %s", name, want, have)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// DefaultMaxTransformBodySize is the default maximum size of the request
+// bodies buffered by TransformRequestBody.
+const DefaultMaxTransformBodySize = 10 << 20
+
+type transformConfig struct {
+	maxBytes int64
+}
+
+// TransformOption sets an optional parameter for TransformRequestBody and
+// TransformRequestBodyStream.
+type TransformOption gkit.Option[*transformConfig]
+
+// TransformMaxBytes bounds the size of the raw request body; a larger body
+// fails with an *http.MaxBytesError. Zero or less means no limit.
+func TransformMaxBytes(n int64) TransformOption {
+	return func(c *transformConfig) { c.maxBytes = n }
+}
+
+// TransformRequestBody returns a ServerOption that reads the whole request
+// body, passes it through fn, and hands the result to the server's decoder as
+// the new body, e.g. to decrypt a payload or to migrate an old schema to the
+// current one without touching each decoder. A body larger than
+// DefaultMaxTransformBodySize, or the size given with TransformMaxBytes,
+// fails with an *http.MaxBytesError before fn is called. An error returned by
+// fn is passed to the error encoder, so it may implement StatusCoder, e.g. to
+// reply 400 to an undecryptable payload.
+//
+// The body is buffered; use TransformRequestBodyStream for large or streamed
+// bodies. When several transformations are given, the one given last is
+// applied first.
+func TransformRequestBody[Req, Res any](fn func([]byte) ([]byte, error), options ...TransformOption) ServerOption[Req, Res] {
+	c := transformConfig{maxBytes: DefaultMaxTransformBodySize}
+	for _, option := range options {
+		option(&c)
+	}
+	maxBytes := c.maxBytes
+
+	return func(s *Server[Req, Res]) {
+		dec := s.dec
+		s.dec = func(ctx context.Context, r *http.Request) (Req, error) {
+			var req Req
+
+			body := r.Body
+			if maxBytes > 0 {
+				body = http.MaxBytesReader(nil, r.Body, maxBytes)
+			}
+			b, err := io.ReadAll(body)
+			r.Body.Close()
+			if err != nil {
+				return req, err
+			}
+
+			if b, err = fn(b); err != nil {
+				return req, err
+			}
+			r.Body = io.NopCloser(bytes.NewReader(b))
+			r.ContentLength = int64(len(b))
+
+			return dec(ctx, r)
+		}
+	}
+}
+
+// TransformRequestBodyStream is like TransformRequestBody, but wraps the body
+// reader with fn instead of buffering it, e.g. with a gzip or cipher stream
+// reader, so the decoder reads the transformed body as it arrives. The raw
+// body read by fn is only bounded if TransformMaxBytes is given. The
+// transformed body has an unknown length.
+func TransformRequestBodyStream[Req, Res any](fn func(io.Reader) (io.Reader, error), options ...TransformOption) ServerOption[Req, Res] {
+	var c transformConfig
+	for _, option := range options {
+		option(&c)
+	}
+	maxBytes := c.maxBytes
+
+	return func(s *Server[Req, Res]) {
+		dec := s.dec
+		s.dec = func(ctx context.Context, r *http.Request) (Req, error) {
+			var req Req
+
+			body := r.Body
+			if maxBytes > 0 {
+				body = http.MaxBytesReader(nil, r.Body, maxBytes)
+			}
+			transformed, err := fn(body)
+			if err != nil {
+				return req, err
+			}
+			r.Body = readCloser{Reader: transformed, Closer: body}
+			r.ContentLength = -1
+
+			return dec(ctx, r)
+		}
+	}
+}
+
+// readCloser reads from a transformed body and closes the original one.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
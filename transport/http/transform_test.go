@@ -0,0 +1,87 @@
+//go:build unit
+
+package http_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type renamed struct {
+	Name string `json:"name"`
+}
+
+func echoRenamed(options ...httptransport.ServerOption[renamed, renamed]) *httptransport.Server[renamed, renamed] {
+	return httptransport.NewServer(
+		func(_ context.Context, req renamed) (renamed, error) { return req, nil },
+		httptransport.DecodeJSONRequest[renamed],
+		httptransport.EncodeJSONResponse[renamed],
+		options...,
+	)
+}
+
+func TestTransformRequestBody(t *testing.T) {
+	// Migrate the old schema, which called the field "title".
+	migrate := func(b []byte) ([]byte, error) {
+		return bytes.ReplaceAll(b, []byte(`"title"`), []byte(`"name"`)), nil
+	}
+	handler := echoRenamed(httptransport.TransformRequestBody[renamed, renamed](migrate))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"title":"old"}`)))
+	if want, have := `{"name":"old"}`, strings.TrimSpace(w.Body.String()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestTransformRequestBodyMaxBytes(t *testing.T) {
+	var called bool
+	handler := echoRenamed(
+		httptransport.TransformRequestBody[renamed, renamed](func(b []byte) ([]byte, error) {
+			called = true
+			return b, nil
+		}, httptransport.TransformMaxBytes(8)),
+		httptransport.ServerErrorEncoder[renamed, renamed](func(_ context.Context, w http.ResponseWriter, err error) {
+			var maxErr *http.MaxBytesError
+			if !errors.As(err, &maxErr) {
+				t.Errorf("want *http.MaxBytesError, have %v", err)
+			}
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"too long"}`)))
+	if want, have := http.StatusRequestEntityTooLarge, w.Code; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+	if called {
+		t.Error("transformation was called on an oversized body")
+	}
+}
+
+func TestTransformRequestBodyStream(t *testing.T) {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	io.WriteString(zw, `{"name":"zipped"}`)
+	zw.Close()
+
+	handler := echoRenamed(httptransport.TransformRequestBodyStream[renamed, renamed](func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", &b))
+	if want, have := `{"name":"zipped"}`, strings.TrimSpace(w.Body.String()); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
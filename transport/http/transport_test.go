@@ -0,0 +1,43 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"github.com/bobobox-id/gkit/core/transporttest"
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+func TestClientTransportCompliance(t *testing.T) {
+	harness := func(t *testing.T, remote gkit.Endpoint[fooRequest, enhancedRequest]) gkit.Transport[fooRequest, enhancedRequest] {
+		server := httptest.NewServer(httptransport.NewServer(
+			remote,
+			httptransport.DecodeJSONRequest[fooRequest],
+			httptransport.EncodeJSONResponse[enhancedRequest],
+		))
+		t.Cleanup(server.Close)
+
+		decodeError := httptransport.DecodeHTTPError(0)
+		return httptransport.NewClient(
+			http.MethodPost,
+			mustParse(server.URL),
+			httptransport.EncodeJSONRequest[fooRequest],
+			func(_ context.Context, r *http.Response) (enhancedRequest, error) {
+				var res enhancedRequest
+				if err := decodeError(r); err != nil {
+					return res, err
+				}
+				err := json.NewDecoder(r.Body).Decode(&res)
+				return res, err
+			},
+		)
+	}
+
+	transporttest.Run[fooRequest, enhancedRequest](t, harness, fooRequest{Foo: "req"}, enhancedRequest{Foo: "res"})
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	gkit "github.com/bobobox-id/gkit/core"
+)
+
+// ErrUnknownDiscriminator is wrapped by the error returned when decoding a
+// JSON object whose discriminator has no type registered with the
+// TypeRegistry, or is missing.
+var ErrUnknownDiscriminator = errors.New("http: unknown discriminator")
+
+// TypeRegistry maps the values of a discriminator field of JSON objects to
+// the concrete types implementing T, to decode tagged unions such as
+// {"type": "card", ...} or {"type": "transfer", ...} into a Payment interface.
+type TypeRegistry[T any] struct {
+	field string
+
+	mu        sync.RWMutex
+	factories map[string]func() T
+}
+
+// NewTypeRegistry returns an empty TypeRegistry reading the discriminator
+// from field, e.g. "type".
+func NewTypeRegistry[T any](field string) *TypeRegistry[T] {
+	return &TypeRegistry[T]{field: field, factories: make(map[string]func() T)}
+}
+
+// RegisterType registers factory for the objects whose discriminator is
+// discriminator. factory returns a new value the object is decoded into,
+// which must be a pointer, e.g. func() Payment { return &CardPayment{} }.
+func (reg *TypeRegistry[T]) RegisterType(discriminator string, factory func() T) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.factories[discriminator] = factory
+}
+
+// Unmarshal decodes the JSON object data into a new value of the type
+// registered for its discriminator.
+func (reg *TypeRegistry[T]) Unmarshal(data []byte) (T, error) {
+	var (
+		res    T
+		fields map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return res, err
+	}
+
+	var discriminator string
+	if raw, ok := fields[reg.field]; ok {
+		if err := json.Unmarshal(raw, &discriminator); err != nil {
+			return res, fmt.Errorf("http: discriminator %q: %w", reg.field, err)
+		}
+	}
+
+	reg.mu.RLock()
+	factory, ok := reg.factories[discriminator]
+	reg.mu.RUnlock()
+	if !ok {
+		return res, fmt.Errorf("%w: %s %q", ErrUnknownDiscriminator, reg.field, discriminator)
+	}
+
+	res = factory()
+	if err := json.Unmarshal(data, any(res)); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// DecodeJSONResponseUnion returns a DecodeResponseFunc like
+// DecodeJSONResponse decoding the JSON object of the response with reg, so
+// the response is of the concrete type registered for its discriminator.
+func DecodeJSONResponseUnion[T any](reg *TypeRegistry[T]) gkit.EncodeDecodeFunc[*http.Response, T] {
+	return func(_ context.Context, resp *http.Response) (T, error) {
+		var res T
+		if !isBufferedStream(resp.Body) {
+			defer resp.Body.Close()
+		}
+
+		if err := DecodeHTTPError(DefaultMaxErrorBodySize)(resp); err != nil {
+			return res, err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return res, err
+		}
+
+		res, err = reg.Unmarshal(data)
+		if err != nil {
+			return res, fmt.Errorf("http: decoding %d %s response: %w", resp.StatusCode, http.StatusText(resp.StatusCode), err)
+		}
+		return res, nil
+	}
+}
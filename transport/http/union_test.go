@@ -0,0 +1,60 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type payment interface{ amount() int }
+
+type cardPayment struct {
+	Last4  string `json:"last4"`
+	Amount int    `json:"amount"`
+}
+
+func (p *cardPayment) amount() int { return p.Amount }
+
+type transferPayment struct {
+	Bank   string `json:"bank"`
+	Amount int    `json:"amount"`
+}
+
+func (p *transferPayment) amount() int { return p.Amount }
+
+func TestDecodeJSONResponseUnion(t *testing.T) {
+	reg := httptransport.NewTypeRegistry[payment]("type")
+	reg.RegisterType("card", func() payment { return &cardPayment{} })
+	reg.RegisterType("transfer", func() payment { return &transferPayment{} })
+	dec := httptransport.DecodeJSONResponseUnion(reg)
+
+	response := func(body string) *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	p, err := dec(context.Background(), response(`{"type":"transfer","bank":"BCA","amount":100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp, ok := p.(*transferPayment); !ok || tp.Bank != "BCA" || tp.amount() != 100 {
+		t.Errorf("want a transfer payment, have %#v", p)
+	}
+
+	p, err = dec(context.Background(), response(`{"last4":"4242","type":"card"}`))
+	if _, ok := p.(*cardPayment); err != nil || !ok {
+		t.Errorf("want a card payment, have %#v, %v", p, err)
+	}
+
+	for _, body := range []string{`{"type":"cash"}`, `{"amount":1}`} {
+		if _, err := dec(context.Background(), response(body)); !errors.Is(err, httptransport.ErrUnknownDiscriminator) {
+			t.Errorf("%s: want %v, have %v", body, httptransport.ErrUnknownDiscriminator, err)
+		}
+	}
+}
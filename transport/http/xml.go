@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+)
+
+// EncodeXMLRequest is an EncodeRequestFunc that serializes the request as
+// XML to the Request body, for clients of XML-over-HTTP services. Like
+// EncodeJSONRequest, it sets Content-Length and GetBody, so the body is
+// replayed on redirects and retries.
+func EncodeXMLRequest[Req any](_ context.Context, r *http.Request, request Req) error {
+	r.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	var b bytes.Buffer
+	if err := xml.NewEncoder(&b).Encode(request); err != nil {
+		return err
+	}
+
+	body := b.Bytes()
+	r.ContentLength = int64(len(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.Body, _ = r.GetBody()
+
+	return nil
+}
+
+// DecodeXMLResponse is a client DecodeResponseFunc that deserializes an XML
+// response body. A non-2xx response is returned as an *HTTPError instead, so
+// a Server proxying the call replies with the backend's status code.
+func DecodeXMLResponse[Res any](_ context.Context, resp *http.Response) (Res, error) {
+	var res Res
+	if err := DecodeHTTPError(DefaultMaxErrorBodySize)(resp); err != nil {
+		return res, err
+	}
+
+	err := xml.NewDecoder(resp.Body).Decode(&res)
+	return res, err
+}
+
+// DecodeXMLRequest is a DecodeRequestFunc that deserializes an XML request
+// body to the domain object.
+func DecodeXMLRequest[Req any](_ context.Context, r *http.Request) (Req, error) {
+	var req Req
+	defer r.Body.Close()
+
+	err := xml.NewDecoder(r.Body).Decode(&req)
+	return req, err
+}
+
+// EncodeXMLResponse is an EncodeResponseFunc that serializes the response as
+// XML to the ResponseWriter. Like EncodeJSONResponse, it applies the headers
+// of a Headerer response and the status code of a StatusCoder response, and
+// a nil pointer response doesn't panic on methods with value receivers.
+func EncodeXMLResponse[Res any](_ context.Context, w http.ResponseWriter, response Res) error {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	setResponseHeaders(w, response)
+
+	code := statusCode(response)
+	w.WriteHeader(code)
+
+	if code == http.StatusNoContent {
+		return nil
+	}
+
+	return xml.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package http_test
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httptransport "github.com/bobobox-id/gkit/transport/http"
+)
+
+type xmlItem struct {
+	XMLName xml.Name `xml:"item"`
+	ID      int      `xml:"id,attr"`
+	Name    string   `xml:"name"`
+}
+
+func TestXMLRequestRoundTrip(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if err := httptransport.EncodeXMLRequest(context.Background(), r, xmlItem{ID: 1, Name: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "application/xml; charset=utf-8", r.Header.Get("Content-Type"); want != have {
+		t.Errorf("Content-Type: want %q, have %q", want, have)
+	}
+
+	have, err := httptransport.DecodeXMLRequest[xmlItem](context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.ID != 1 || have.Name != "one" {
+		t.Errorf("want {1 one}, have %+v", have)
+	}
+}
+
+func TestEncodeXMLRequestReplayable(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if err := httptransport.EncodeXMLRequest(context.Background(), r, xmlItem{ID: 1, Name: "one"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := io.ReadAll(r.Body)
+	if want, have := int64(len(first)), r.ContentLength; want != have {
+		t.Errorf("ContentLength: want %d, have %d", want, have)
+	}
+	if r.GetBody == nil {
+		t.Fatal("want GetBody set")
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := io.ReadAll(body); string(first) != string(again) {
+		t.Errorf("GetBody: want %s, have %s", first, again)
+	}
+}
+
+func TestXMLResponseRoundTrip(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := httptransport.EncodeXMLResponse(context.Background(), w, xmlItem{ID: 2, Name: "two"}); err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := httptransport.DecodeXMLResponse[xmlItem](context.Background(), w.Result())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.ID != 2 || have.Name != "two" {
+		t.Errorf("want {2 two}, have %+v", have)
+	}
+}
+
+func TestDecodeXMLResponseError(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       io.NopCloser(strings.NewReader("<error>no such item</error>")),
+	}
+
+	_, err := httptransport.DecodeXMLResponse[xmlItem](context.Background(), resp)
+	var httpErr *httptransport.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("want *HTTPError, have %v", err)
+	}
+	if want, have := http.StatusNotFound, httpErr.StatusCode(); want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestEncodeXMLResponseNil(t *testing.T) {
+	w := httptest.NewRecorder()
+	if err := httptransport.EncodeXMLResponse[*createdResponse](context.Background(), w, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := http.StatusOK, w.Code; want != have {
+		t.Errorf("StatusCode: want %d, have %d", want, have)
+	}
+}
@@ -3,7 +3,7 @@ module github.com/bobobox-id/gkit/transport/jetstream
 go 1.21.6
 
 require (
-	github.com/bobobox-id/gkit/core v0.1.0
+	github.com/bobobox-id/gkit/core v0.2.0
 	github.com/nats-io/nats-server/v2 v2.10.10
 	github.com/nats-io/nats.go v1.32.0
 )
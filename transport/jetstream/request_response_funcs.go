@@ -0,0 +1,35 @@
+package jetstream
+
+import (
+	"context"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// SetMsgDeadline is a PublisherBefore func that sends the time left until the
+// context deadline in the gkit.TimeoutKey header of the message. Since the
+// publishing context is bounded by PublisherTimeout, that's what is sent
+// unless the caller's deadline is sooner. The budget counts from when the
+// message is delivered, so time spent waiting in the stream isn't deducted.
+func SetMsgDeadline(ctx context.Context, msg *nats.Msg) context.Context {
+	if deadline, ok := ctx.Deadline(); ok {
+		if msg.Header == nil {
+			msg.Header = nats.Header{}
+		}
+		msg.Header.Set(gkit.TimeoutKey, gkit.FormatTimeout(time.Until(deadline)))
+	}
+	return ctx
+}
+
+// PopulateMsgDeadline is a SubscriberBefore func that stores the time budget
+// sent by SetMsgDeadline with gkit.WithPropagatedTimeout. Wrap the endpoint
+// with gkit.PropagateDeadline to apply it. Malformed values are ignored.
+func PopulateMsgDeadline(ctx context.Context, msg jetstream.Msg) context.Context {
+	if d, err := gkit.ParseTimeout(msg.Headers().Get(gkit.TimeoutKey)); err == nil {
+		ctx = gkit.WithPropagatedTimeout(ctx, d)
+	}
+	return ctx
+}
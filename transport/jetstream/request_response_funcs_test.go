@@ -0,0 +1,73 @@
+//go:build unit
+
+package jetstream_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	gkit "github.com/bobobox-id/gkit/core"
+	jstransport "github.com/bobobox-id/gkit/transport/jetstream"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// headersMsg is a jetstream.Msg only able to report its headers.
+type headersMsg struct {
+	jetstream.Msg
+	header nats.Header
+}
+
+func (m headersMsg) Headers() nats.Header { return m.header }
+
+func TestMsgDeadlinePropagation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	msg := nats.NewMsg("events")
+	jstransport.SetMsgDeadline(ctx, msg)
+	if msg.Header.Get(gkit.TimeoutKey) == "" {
+		t.Fatalf("want %s header, have none", gkit.TimeoutKey)
+	}
+
+	d, ok := gkit.PropagatedTimeoutFromContext(jstransport.PopulateMsgDeadline(context.Background(), headersMsg{header: msg.Header}))
+	if !ok || d <= 0 || d > time.Second {
+		t.Errorf("want a propagated timeout within 1s, have %v", d)
+	}
+
+	if _, ok := gkit.PropagatedTimeoutFromContext(jstransport.PopulateMsgDeadline(context.Background(), headersMsg{})); ok {
+		t.Error("want no propagated timeout without the header")
+	}
+}
+
+// dataMsg is a jetstream.Msg only able to report its data.
+type dataMsg struct {
+	jetstream.Msg
+	data []byte
+}
+
+func (m dataMsg) Data() []byte { return m.data }
+
+func TestDecodeJSONRequestWith(t *testing.T) {
+	msg := dataMsg{data: []byte(`{"amount": 12345678901234567890, "note": "x"}`)}
+
+	have, err := jstransport.DecodeJSONRequestWith[map[string]any](gkit.UseNumber)(context.Background(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := json.Number("12345678901234567890"), have["amount"]; want != have {
+		t.Errorf("UseNumber: want %v, have %v (%T)", want, have, have)
+	}
+
+	type payment struct {
+		Amount json.Number `json:"amount"`
+	}
+	if _, err := jstransport.DecodeJSONRequestWith[payment](gkit.DisallowUnknownFields)(context.Background(), msg); err == nil {
+		t.Error("DisallowUnknownFields: want an error for the unknown field note, have none")
+	}
+	if _, err := jstransport.DecodeJSONRequestWith[payment]()(context.Background(), msg); err != nil {
+		t.Errorf("no options: want unknown fields ignored, have %v", err)
+	}
+}
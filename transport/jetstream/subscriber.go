@@ -159,15 +159,29 @@ func (s Subscriber[Req, Res]) HandleMessage(js jetstream.JetStream) func(jetstre
 }
 
 // DecodeJSONRequest is a DecodeRequestFunc that deserialize JSON to domain object.
-func DecodeJSONRequest[Req any](_ context.Context, msg jetstream.Msg) (Req, error) {
-	var req Req
+func DecodeJSONRequest[Req any](ctx context.Context, msg jetstream.Msg) (Req, error) {
+	return DecodeJSONRequestWith[Req]()(ctx, msg)
+}
 
-	err := json.NewDecoder(bytes.NewReader(msg.Data())).Decode(&req)
-	if err != nil {
-		return req, err
-	}
+// DecodeJSONRequestWith returns a DecodeRequestFunc like DecodeJSONRequest
+// whose json.Decoder is configured with options, e.g. gkit.UseNumber to keep
+// numbers decoded into any fields as json.Number.
+func DecodeJSONRequestWith[Req any](options ...gkit.JSONDecoderOption) gkit.EncodeDecodeFunc[jetstream.Msg, Req] {
+	return func(_ context.Context, msg jetstream.Msg) (Req, error) {
+		var req Req
+
+		dec := json.NewDecoder(bytes.NewReader(msg.Data()))
+		for _, option := range options {
+			option(dec)
+		}
 
-	return req, nil
+		err := dec.Decode(&req)
+		if err != nil {
+			return req, err
+		}
+
+		return req, nil
+	}
 }
 
 // EncodeJSONResponse is a EncodeResponseFunc that serializes the response as a